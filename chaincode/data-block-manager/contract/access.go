@@ -0,0 +1,441 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	accessRequestObjectType = "access~request"
+	accessGrantObjectType   = "access~grant"
+)
+
+// status of an access request
+const (
+	accessPending = "pending"
+	accessGranted = "granted"
+	accessDenied  = "denied"
+	accessRevoked = "revoked"
+)
+
+// AccessRequest is kept on the public ledger so that both the owner and the requester can follow it
+type AccessRequest struct {
+	ID        string `json:"id"`
+	DatasetID string `json:"datasetID"`
+	// MSP ID of the owning organisation
+	Owner string `json:"owner"`
+	// MSP ID and client ID of the requesting organisation
+	Requester       string `json:"requester"`
+	RequesterClient string `json:"requesterClient"`
+	Purpose         string `json:"purpose"`
+	Status          string `json:"status"`
+	// Reason given by the owner on denial or revocation
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// AccessGrant is written by the owner into the implicit collection of the requester
+type AccessGrant struct {
+	DatasetID string `json:"datasetID"`
+	RequestID string `json:"requestID"`
+	Owner     string `json:"owner"`
+	Endpoint  string `json:"endpoint"`
+	Terms     string `json:"terms"`
+	// Hash of the dataset terms accepted by the requester
+	TermsHash string `json:"termsHash"`
+	GrantedAt string `json:"grantedAt"`
+}
+
+func (r *AccessRequest) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode access request to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (r *AccessRequest) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, r)
+	if err != nil {
+		return fmt.Errorf("Failed to decode access request.\n%v", err)
+	}
+
+	return nil
+}
+
+func (g *AccessGrant) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*g)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode access grant to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (g *AccessGrant) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, g)
+	if err != nil {
+		return fmt.Errorf("Failed to decode access grant.\n%v", err)
+	}
+
+	return nil
+}
+
+func accessRequestKey(ctx contractapi.TransactionContextInterface, datasetID string, requestID string) (string, error) {
+	return createCompositeKey(ctx, accessRequestObjectType, datasetID, requestID)
+}
+
+// grants are keyed by request, so that revoking one leaves the others of the requester in place
+func accessGrantKey(ctx contractapi.TransactionContextInterface, datasetID string, requestID string) (string, error) {
+	return createCompositeKey(ctx, accessGrantObjectType, datasetID, requestID)
+}
+
+func readAccessRequest(ctx contractapi.TransactionContextInterface, datasetID string, requestID string) (*AccessRequest, error) {
+	key, err := accessRequestKey(ctx, datasetID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, fmt.Errorf(`Access request "%s" for dataset "%s" does not exist.`, requestID, datasetID)
+	}
+
+	req := new(AccessRequest)
+	if err := req.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func writeAccessRequest(ctx contractapi.TransactionContextInterface, req *AccessRequest) error {
+	key, err := accessRequestKey(ctx, req.DatasetID, req.ID)
+	if err != nil {
+		return err
+	}
+	bs, err := req.ToBytes()
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return fmt.Errorf(`Failed to write access request "%s" : %v`, req.ID, err)
+	}
+
+	return nil
+}
+
+// requireAccessOwner loads a request that the client, as owner of the dataset, may decide on
+func requireAccessOwner(ctx contractapi.TransactionContextInterface, datasetID string, requestID string) (*AccessRequest, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opGrantAccess); err != nil {
+		return nil, err
+	}
+
+	req, err := readAccessRequest(ctx, datasetID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Owner != mspID {
+		return nil, fmt.Errorf(`Access request "%s" is addressed to Org "%s", not "%s".`, requestID, req.Owner, mspID)
+	}
+
+	if err := requireNotRetired(ctx, implicitPrivateDataCollection(mspID), datasetID); err != nil {
+		return nil, err
+	}
+	if err := requireNotExpired(ctx, implicitPrivateDataCollection(mspID), datasetID); err != nil {
+		return nil, err
+	}
+	reg, err := readRegistration(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// changeAccessStatus moves a request on and announces it with a chaincode event
+func changeAccessStatus(ctx contractapi.TransactionContextInterface, req *AccessRequest, from string, to string, event string) error {
+	if req.Status != from {
+		return fmt.Errorf(`Access request "%s" is %s, expected %s.`, req.ID, req.Status, from)
+	}
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	req.Status = to
+	req.UpdatedAt = ts
+	if err := writeAccessRequest(ctx, req); err != nil {
+		return err
+	}
+
+	return emitAccessEvent(ctx, event, req)
+}
+
+// emitAccessEvent announces a change of an access request without its purpose or reason
+func emitAccessEvent(ctx contractapi.TransactionContextInterface, event string, req *AccessRequest) error {
+	collections := []string{}
+	if req.Status == accessGranted || req.Status == accessRevoked {
+		collections = append(collections, implicitPrivateDataCollection(req.Requester))
+	}
+
+	return emitDatasetEvent(ctx, event, &DatasetEvent{
+		ID:          req.DatasetID,
+		Owner:       req.Owner,
+		Collections: collections,
+		RequestID:   req.ID,
+		Requester:   req.Requester,
+	})
+}
+
+// RequestAccess files a request of the client's organisation for the private endpoint of a dataset
+func (l *DatasetMetadataLedger) RequestAccess(ctx contractapi.TransactionContextInterface, datasetID string, owner string, purpose string) (*AccessRequest, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opRequestAccess); err != nil {
+		return nil, err
+	}
+
+	if owner == mspID {
+		return nil, fmt.Errorf(`Org "%s" already owns dataset "%s".`, mspID, datasetID)
+	}
+	registered, err := isRegisteredBy(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if !registered {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, owner)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &AccessRequest{
+		ID:              ctx.GetStub().GetTxID(),
+		DatasetID:       datasetID,
+		Owner:           owner,
+		Requester:       mspID,
+		RequesterClient: clientID,
+		Purpose:         purpose,
+		Status:          accessPending,
+		CreatedAt:       ts,
+		UpdatedAt:       ts,
+	}
+	if err := writeAccessRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := emitAccessEvent(ctx, eventAccessRequested, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// GrantAccess shares the endpoint, with the access terms given in transient, into the implicit collection of the requester.
+// The requester must have accepted the current terms of the dataset.
+func (l *DatasetMetadataLedger) GrantAccess(ctx contractapi.TransactionContextInterface, datasetID string, requestID string) error {
+	req, err := requireAccessOwner(ctx, datasetID, requestID)
+	if err != nil {
+		return err
+	}
+
+	transient, err := getTxTransient(ctx)
+	if err != nil {
+		return err
+	}
+	terms := string(transient["terms"])
+
+	// The endpoint is only released to a requester that accepted the current terms of the owner
+	acceptance, err := requireAcceptedTerms(ctx, req.Owner, datasetID, req.Requester, req.RequesterClient)
+	if err != nil {
+		return err
+	}
+
+	mdAsBytes, err := readFromCollection(ctx, implicitPrivateDataCollection(req.Owner), datasetID)
+	if err != nil {
+		return err
+	}
+	md := new(DatasetMetadata)
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return err
+	}
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	grant := &AccessGrant{
+		DatasetID: datasetID,
+		RequestID: requestID,
+		Owner:     req.Owner,
+		Endpoint:  md.Endpoint,
+		Terms:     terms,
+		TermsHash: acceptance.TermsHash,
+		GrantedAt: ts,
+	}
+	grantAsBytes, err := grant.ToBytes()
+	if err != nil {
+		return err
+	}
+	grantKey, err := accessGrantKey(ctx, datasetID, requestID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitPrivateDataCollection(req.Requester), grantKey, grantAsBytes); err != nil {
+		return fmt.Errorf(`Failed to share dataset "%s" with Org "%s" : %v`, datasetID, req.Requester, err)
+	}
+
+	return changeAccessStatus(ctx, req, accessPending, accessGranted, eventAccessGranted)
+}
+
+// DenyAccess turns down a pending request
+func (l *DatasetMetadataLedger) DenyAccess(ctx contractapi.TransactionContextInterface, datasetID string, requestID string, reason string) error {
+	req, err := requireAccessOwner(ctx, datasetID, requestID)
+	if err != nil {
+		return err
+	}
+
+	req.Reason = reason
+	return changeAccessStatus(ctx, req, accessPending, accessDenied, eventAccessDenied)
+}
+
+// RevokeAccess withdraws a granted request and removes the endpoint from the implicit collection of the requester
+func (l *DatasetMetadataLedger) RevokeAccess(ctx contractapi.TransactionContextInterface, datasetID string, requestID string, reason string) error {
+	req, err := requireAccessOwner(ctx, datasetID, requestID)
+	if err != nil {
+		return err
+	}
+
+	grantKey, err := accessGrantKey(ctx, datasetID, requestID)
+	if err != nil {
+		return err
+	}
+	if err := deleteFromCollection(ctx, implicitPrivateDataCollection(req.Requester), grantKey); err != nil {
+		return err
+	}
+
+	req.Reason = reason
+	return changeAccessStatus(ctx, req, accessGranted, accessRevoked, eventAccessRevoked)
+}
+
+// QueryAccessRequests lists the access requests filed for a dataset. The owning organisation sees every
+// request addressed to it, any other client only the requests it filed itself.
+func (l *DatasetMetadataLedger) QueryAccessRequests(ctx contractapi.TransactionContextInterface, datasetID string) ([]*AccessRequest, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryAccess); err != nil {
+		return nil, err
+	}
+
+	isOwner, err := isRegisteredBy(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := ctx.GetStub().GetStateByPartialCompositeKey(accessRequestObjectType, []string{datasetID})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read access requests for dataset "%s" : %v`, datasetID, err)
+	}
+	defer it.Close()
+
+	result := []*AccessRequest{}
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		req := new(AccessRequest)
+		if err := req.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		if (isOwner && req.Owner == mspID) || req.RequesterClient == clientID {
+			result = append(result, req)
+		}
+	}
+
+	return result, nil
+}
+
+// QueryAccessGrant returns the endpoint and terms of a dataset of the owner shared with the client's
+// organisation, by the latest grant if several requests were granted
+func (l *DatasetMetadataLedger) QueryAccessGrant(ctx contractapi.TransactionContextInterface, datasetID string, owner string) (*AccessGrant, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryAccess); err != nil {
+		return nil, err
+	}
+
+	it, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(implicitPrivateDataCollection(mspID), accessGrantObjectType, []string{datasetID})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read access grants for dataset "%s" : %v`, datasetID, err)
+	}
+	defer it.Close()
+
+	var grant *AccessGrant
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		g := new(AccessGrant)
+		if err := g.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		if g.Owner != owner {
+			continue
+		}
+		if grant == nil || g.GrantedAt > grant.GrantedAt {
+			grant = g
+		}
+	}
+	if grant == nil {
+		return nil, fmt.Errorf(`Org "%s" has no access granted to dataset "%s" of Org "%s".`, mspID, datasetID, owner)
+	}
+
+	// Terms may have changed or expired since the grant, the requester must have accepted the current ones
+	req, err := readAccessRequest(ctx, datasetID, grant.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := requireAcceptedTerms(ctx, owner, datasetID, req.Requester, req.RequesterClient); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
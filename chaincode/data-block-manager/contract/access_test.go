@@ -0,0 +1,148 @@
+package contract
+
+import (
+	"testing"
+)
+
+// acceptExampleTerms attaches terms to the example dataset of Org1 and has Org2 accept them
+func acceptExampleTerms(t *testing.T, stub *testStub) {
+	t.Helper()
+	l := new(DatasetMetadataLedger)
+	terms, err := l.SetTerms(as(t, stub, org1Registrar, nil), exampleID, `{"allowedPurposes": ["research"]}`)
+	if err != nil {
+		t.Fatalf("SetTerms: %v", err)
+	}
+	if _, err := l.AcceptTerms(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", terms.Hash, "research"); err != nil {
+		t.Fatalf("AcceptTerms: %v", err)
+	}
+}
+
+func TestRequestAccessOwner(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	tests := []struct {
+		name    string
+		dataset string
+		owner   string
+		err     string
+	}{
+		{name: "owner", dataset: exampleID, owner: "Org1MSP"},
+		{name: "other owner", dataset: exampleID, owner: "Org3MSP", err: `Dataset "org1.example.com/data001" is not registered by Org "Org3MSP".`},
+		{name: "unknown dataset", dataset: "org1.example.com/none", owner: "Org1MSP", err: `is not registered by Org "Org1MSP"`},
+		{name: "own dataset", dataset: exampleID, owner: "Org2MSP", err: `Org "Org2MSP" already owns`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := l.RequestAccess(as(t, stub, org2Reader, nil), tt.dataset, tt.owner, "research")
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("RequestAccess: %v", err)
+			}
+			if req.Status != accessPending || req.Requester != "Org2MSP" {
+				t.Errorf("request = %+v", req)
+			}
+		})
+	}
+}
+
+func TestRevokeAccessKeepsOtherGrants(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+	acceptExampleTerms(t, stub)
+
+	requestIDs := []string{}
+	for i := 0; i < 2; i++ {
+		req, err := l.RequestAccess(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", "research")
+		if err != nil {
+			t.Fatalf("RequestAccess: %v", err)
+		}
+		if err := l.GrantAccess(as(t, stub, org1Registrar, nil), exampleID, req.ID); err != nil {
+			t.Fatalf("GrantAccess: %v", err)
+		}
+		requestIDs = append(requestIDs, req.ID)
+	}
+
+	grant, err := l.QueryAccessGrant(as(t, stub, org2Reader, nil), exampleID, "Org1MSP")
+	if err != nil {
+		t.Fatalf("QueryAccessGrant: %v", err)
+	}
+	if grant.RequestID != requestIDs[1] || grant.Endpoint != "https://api.org1.example.com" {
+		t.Errorf("grant = %+v, want the one of request %s", grant, requestIDs[1])
+	}
+
+	if err := l.RevokeAccess(as(t, stub, org1Registrar, nil), exampleID, requestIDs[1], "done"); err != nil {
+		t.Fatalf("RevokeAccess: %v", err)
+	}
+	grant, err = l.QueryAccessGrant(as(t, stub, org2Reader, nil), exampleID, "Org1MSP")
+	if err != nil {
+		t.Fatalf("QueryAccessGrant after revoking one grant: %v", err)
+	}
+	if grant.RequestID != requestIDs[0] {
+		t.Errorf("grant of request %s left, want %s", grant.RequestID, requestIDs[0])
+	}
+
+	t.Run("revoked twice", func(t *testing.T) {
+		err := l.RevokeAccess(as(t, stub, org1Registrar, nil), exampleID, requestIDs[1], "again")
+		requireError(t, err, "is revoked, expected granted")
+	})
+
+	t.Run("all revoked", func(t *testing.T) {
+		if err := l.RevokeAccess(as(t, stub, org1Registrar, nil), exampleID, requestIDs[0], "done"); err != nil {
+			t.Fatalf("RevokeAccess: %v", err)
+		}
+		_, err := l.QueryAccessGrant(as(t, stub, org2Reader, nil), exampleID, "Org1MSP")
+		requireError(t, err, `Org "Org2MSP" has no access granted`)
+	})
+}
+
+func TestQueryAccessRequests(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	requestIDs := map[*testIdentity]string{}
+	for _, id := range []*testIdentity{org2Reader, org2Registrar} {
+		req, err := l.RequestAccess(as(t, stub, id, nil), exampleID, "Org1MSP", "research")
+		if err != nil {
+			t.Fatalf("RequestAccess: %v", err)
+		}
+		requestIDs[id] = req.ID
+	}
+
+	tests := []struct {
+		name string
+		id   *testIdentity
+		want []string
+	}{
+		{name: "owner", id: org1Reader, want: []string{requestIDs[org2Reader], requestIDs[org2Registrar]}},
+		{name: "requester", id: org2Reader, want: []string{requestIDs[org2Reader]}},
+		{name: "other requester", id: org2Registrar, want: []string{requestIDs[org2Registrar]}},
+		{name: "bystander", id: org2Admin, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, err := l.QueryAccessRequests(as(t, stub, tt.id, nil), exampleID)
+			if err != nil {
+				t.Fatalf("QueryAccessRequests: %v", err)
+			}
+			got := map[string]bool{}
+			for _, req := range reqs {
+				got[req.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("requests = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("request %s missing from %v", id, got)
+				}
+			}
+		})
+	}
+}
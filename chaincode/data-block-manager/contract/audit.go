@@ -0,0 +1,234 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const accessLogObjectType = "access~log"
+
+// access log timestamps are stored in UTC with a fixed layout so that entries sort by time
+const accessLogLayout = "2006-01-02T15:04:05.000000000Z"
+
+// maxAccessLogPageSize caps the page size of GetAccessLog
+const maxAccessLogPageSize = 200
+
+// AccessLogEntry records a consumer fetching a dataset from its endpoint
+type AccessLogEntry struct {
+	DatasetID string `json:"datasetID"`
+	// MSP ID and client ID of the consumer
+	Consumer       string `json:"consumer"`
+	ConsumerClient string `json:"consumerClient"`
+	Purpose        string `json:"purpose"`
+	// Granted access request the fetch relies on, if any
+	RequestID  string `json:"requestID,omitempty"`
+	AccessedAt string `json:"accessedAt"`
+	TxID       string `json:"txID"`
+}
+
+// AccessLogPage is one page of the access log, pass Bookmark to fetch the next page
+type AccessLogPage struct {
+	Entries  []*AccessLogEntry `json:"entries"`
+	Bookmark string            `json:"bookmark"`
+}
+
+func (e *AccessLogEntry) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*e)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode access log entry to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (e *AccessLogEntry) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, e)
+	if err != nil {
+		return fmt.Errorf("Failed to decode access log entry.\n%v", err)
+	}
+
+	return nil
+}
+
+// parseLogTime normalises an RFC3339 bound of GetAccessLog, an empty bound stays empty
+func parseLogTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return "", fmt.Errorf(`Time "%s" is not an RFC3339 date : %v`, value, err)
+	}
+
+	return t.UTC().Format(accessLogLayout), nil
+}
+
+// grantedRequestID returns the ID of a granted access request of the organisation for the dataset, if any
+func grantedRequestID(ctx contractapi.TransactionContextInterface, datasetID string, mspID string) (string, error) {
+	it, err := ctx.GetStub().GetStateByPartialCompositeKey(accessRequestObjectType, []string{datasetID})
+	if err != nil {
+		return "", fmt.Errorf(`Failed to read access requests for dataset "%s" : %v`, datasetID, err)
+	}
+	defer it.Close()
+
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return "", err
+		}
+		req := new(AccessRequest)
+		if err := req.FromBytes(item.Value); err != nil {
+			return "", err
+		}
+		if req.Requester == mspID && req.Status == accessGranted {
+			return req.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// RecordAccess logs that the client fetched a dataset from its endpoint. The entry is written to the
+// implicit collection of the owner, so that only the owner learns the access pattern. The owner is
+// checked against the hash of its registration, so accesses to unknown or retired datasets are not
+// logged and entries cannot be written on behalf of another organisation.
+func (l *DatasetMetadataLedger) RecordAccess(ctx contractapi.TransactionContextInterface, datasetID string, owner string, purpose string) (*AccessLogEntry, error) {
+	if err := requireCertification(ctx, opRecordAccess); err != nil {
+		return nil, err
+	}
+
+	if err := requireNotRetired(ctx, "", datasetID); err != nil {
+		return nil, err
+	}
+	registered, err := isRegisteredBy(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if !registered {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, owner)
+	}
+
+	mspID, err := getClientMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requestID, err := grantedRequestID(ctx, datasetID, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	entry := &AccessLogEntry{
+		DatasetID:      datasetID,
+		Consumer:       mspID,
+		ConsumerClient: clientID,
+		Purpose:        purpose,
+		RequestID:      requestID,
+		AccessedAt:     now.UTC().Format(accessLogLayout),
+		TxID:           txID,
+	}
+	bs, err := entry.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := createCompositeKey(ctx, accessLogObjectType, datasetID, entry.AccessedAt, txID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitPrivateDataCollection(owner), key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to log access to dataset "%s" for Org "%s" : %v`, datasetID, owner, err)
+	}
+
+	return entry, nil
+}
+
+// GetAccessLog returns the accesses to a dataset of the client's organisation between from and to,
+// oldest first and one page at a time. Empty bounds leave the period open.
+func (l *DatasetMetadataLedger) GetAccessLog(ctx contractapi.TransactionContextInterface, datasetID string, from string, to string, pageSize int, bookmark string) (*AccessLogPage, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryPrivate); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, mspID)
+	}
+
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("Page size must be positive, got %d.", pageSize)
+	}
+	if pageSize > maxAccessLogPageSize {
+		pageSize = maxAccessLogPageSize
+	}
+	fromTs, err := parseLogTime(from)
+	if err != nil {
+		return nil, err
+	}
+	toTs, err := parseLogTime(to)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(implicitPrivateDataCollection(mspID), accessLogObjectType, []string{datasetID})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read access log of dataset "%s" : %v`, datasetID, err)
+	}
+	defer it.Close()
+
+	// the bookmark is the key of the last entry of the previous page, and it is only returned
+	// when another entry follows the page
+	page := &AccessLogPage{Entries: []*AccessLogEntry{}}
+	last := ""
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if bookmark != "" && item.Key <= bookmark {
+			continue
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(item.Key)
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to split composite key "%s" : %v`, item.Key, err)
+		}
+		accessedAt := attributes[1]
+		if fromTs != "" && accessedAt < fromTs {
+			continue
+		}
+		if toTs != "" && accessedAt >= toTs {
+			break
+		}
+		if len(page.Entries) == pageSize {
+			page.Bookmark = last
+			break
+		}
+
+		entry := new(AccessLogEntry)
+		if err := entry.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+		last = item.Key
+	}
+
+	return page, nil
+}
@@ -0,0 +1,89 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestRecordAccess(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const retiredID = "org1.example.com/retired"
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+	mustRegister(t, stub, org1Registrar, metadataWithID(retiredID), "")
+	if err := l.Deregister(as(t, stub, org1Registrar, nil), retiredID, "test"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		dataset string
+		owner   string
+		err     string
+	}{
+		{name: "owner", dataset: exampleID, owner: "Org1MSP"},
+		{name: "other owner", dataset: exampleID, owner: "Org2MSP", err: `Dataset "org1.example.com/data001" is not registered by Org "Org2MSP".`},
+		{name: "unknown dataset", dataset: "org1.example.com/none", owner: "Org1MSP", err: `Dataset "org1.example.com/none" is not registered by Org "Org1MSP".`},
+		{name: "retired dataset", dataset: retiredID, owner: "Org1MSP", err: `Dataset "org1.example.com/retired" was retired`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := l.RecordAccess(as(t, stub, org2Reader, nil), tt.dataset, tt.owner, "research")
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("RecordAccess: %v", err)
+			}
+			if entry.Consumer != "Org2MSP" || entry.DatasetID != tt.dataset {
+				t.Errorf("entry = %+v", entry)
+			}
+		})
+	}
+
+	if logged := stub.state[implicitPrivateDataCollection("Org2MSP")]; len(logged) > 0 {
+		t.Errorf("accesses logged to Org2MSP, which owns no dataset: %d entries", len(logged))
+	}
+}
+
+func TestGetAccessLogBookmark(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+	for i := 0; i < 4; i++ {
+		if _, err := l.RecordAccess(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", "research"); err != nil {
+			t.Fatalf("RecordAccess: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		to       string
+		pageSize int
+		pages    []int
+	}{
+		{name: "partial last page", pageSize: 3, pages: []int{3, 1}},
+		{name: "exact last page", pageSize: 2, pages: []int{2, 2}},
+		{name: "single page", pageSize: 4, pages: []int{4}},
+		{name: "page above log", pageSize: 10, pages: []int{4}},
+		{name: "page ending at bound", to: "2023-01-01T00:00:04Z", pageSize: 2, pages: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookmark := ""
+			for i, want := range tt.pages {
+				page, err := l.GetAccessLog(as(t, stub, org1Registrar, nil), exampleID, "", tt.to, tt.pageSize, bookmark)
+				if err != nil {
+					t.Fatalf("page %d: %v", i, err)
+				}
+				if len(page.Entries) != want {
+					t.Fatalf("page %d has %d entries, want %d", i, len(page.Entries), want)
+				}
+				bookmark = page.Bookmark
+				if last := i == len(tt.pages)-1; last != (bookmark == "") {
+					t.Fatalf("page %d has bookmark %q", i, bookmark)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package contract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxBatchSize bounds the number of datasets registered in one transaction
+const maxBatchSize = 500
+
+// BatchItemResult reports the outcome of one item of a batch, in input order
+type BatchItemResult struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Revision int    `json:"revision,omitempty"`
+	// Reason the item was rejected for, empty if it was registered
+	Error string `json:"error,omitempty"`
+}
+
+// batchRejectedCode prefixes the message of a BatchRejection, so that clients can find it among
+// the peer messages and decode the rest
+const batchRejectedCode = "BATCH_REJECTED"
+
+// BatchRejection is returned when any item of a batch is invalid. A batch is all-or-nothing, so no
+// item of it is registered and Failures lists each failing item with its reason.
+type BatchRejection struct {
+	Total    int                `json:"total"`
+	Failures []*BatchItemResult `json:"failures"`
+}
+
+func (r *BatchRejection) Error() string {
+	bs, err := json.Marshal(*r)
+	if err != nil {
+		return fmt.Sprintf("%s: %d of %d items are invalid", batchRejectedCode, len(r.Failures), r.Total)
+	}
+
+	return batchRejectedCode + ": " + string(bs)
+}
+
+// decodeBatch splits a JSON array or NDJSON of metadata into its items
+func decodeBatch(bs []byte) ([][]byte, error) {
+	bs = bytes.TrimSpace(bs)
+	if len(bs) == 0 {
+		return nil, fmt.Errorf("Batch is empty.")
+	}
+
+	if bs[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(bs, &items); err != nil {
+			return nil, fmt.Errorf("Failed to decode batch : %v", err)
+		}
+		result := make([][]byte, len(items))
+		for i, item := range items {
+			result[i] = item
+		}
+		return result, nil
+	}
+
+	result := [][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(bs))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(bs)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		result = append(result, append([]byte{}, line...))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to decode batch : %v", err)
+	}
+
+	return result, nil
+}
+
+// itemID returns the ID of an item that failed to decode or validate, if it has one
+func itemID(item []byte) string {
+	var doc struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(item, &doc); err != nil {
+		return ""
+	}
+
+	return doc.ID
+}
+
+// RegisterBatch registers every dataset of the JSON array or NDJSON in transient "metadata" to the
+// collections in transient "collections", with the retention in transient "retention" if given.
+// All items are validated before anything is written, and if any of them is invalid the whole
+// batch is rejected with a BatchRejection giving the index and reason of each failing item.
+func (l *DatasetMetadataLedger) RegisterBatch(ctx contractapi.TransactionContextInterface) ([]*BatchItemResult, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opRegister); err != nil {
+		return nil, err
+	}
+
+	transient, err := getTxTransient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read metadata input from transient
+	batchAsBytes, ok := transient["metadata"]
+	if !ok {
+		return nil, fmt.Errorf("Dataset metadata not defined in transient.")
+	}
+	// Read collections to register from transient
+	collectionsAsBytes, ok := transient["collections"]
+	if !ok {
+		return nil, fmt.Errorf("Collections not defined in transient.")
+	}
+	var collections []string
+	if err := json.Unmarshal(collectionsAsBytes, &collections); err != nil {
+		return nil, fmt.Errorf("Failed to decode collections : %v", err)
+	}
+
+	retentionClass, expiresAt, err := readRetentionInput(ctx, transient)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := decodeBatch(batchAsBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > maxBatchSize {
+		return nil, fmt.Errorf("Batch of %d datasets exceeds the limit of %d.", len(items), maxBatchSize)
+	}
+
+	// Validate every item before writing any of them
+	mds := make([]*DatasetMetadata, len(items))
+	ids := make([]string, len(items))
+	rejection := &BatchRejection{Total: len(items), Failures: []*BatchItemResult{}}
+	for i, item := range items {
+		md, err := prepareRegistration(ctx, mspID, item, collections)
+		if err != nil {
+			rejection.Failures = append(rejection.Failures, &BatchItemResult{Index: i, ID: itemID(item), Error: err.Error()})
+			continue
+		}
+		if containsString(ids, md.ID) {
+			rejection.Failures = append(rejection.Failures, &BatchItemResult{Index: i, ID: md.ID, Error: fmt.Sprintf(`Dataset "%s" appears more than once in the batch.`, md.ID)})
+			continue
+		}
+		mds[i] = md
+		ids[i] = md.ID
+	}
+	if len(rejection.Failures) > 0 {
+		return nil, rejection
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := readDisclosurePolicy(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*BatchItemResult, len(mds))
+	for i, md := range mds {
+		if err := writeNewDataset(ctx, policy, &DatasetRegistration{
+			ID:          md.ID,
+			Owner:       mspID,
+			Registrar:   clientID,
+			Revision:    1,
+			Collections: collections,
+			CreatedAt:   ts,
+			UpdatedAt:   ts,
+
+			RetentionClass: retentionClass,
+			ExpiresAt:      expiresAt,
+		}, md); err != nil {
+			rejection.Failures = append(rejection.Failures, &BatchItemResult{Index: i, ID: md.ID, Error: err.Error()})
+			return nil, rejection
+		}
+		results[i] = &BatchItemResult{Index: i, ID: md.ID, Revision: 1}
+	}
+
+	if err := emitDatasetEvent(ctx, eventDatasetsRegistered, &DatasetEvent{
+		IDs:         ids,
+		Owner:       mspID,
+		Collections: collections,
+		Revision:    1,
+	}); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,91 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegisterBatch(t *testing.T) {
+	compact := func(id string) string {
+		return strings.Join(strings.Fields(metadataWithID(id)), " ")
+	}
+	invalid := `{"id": "org1.example.com/invalid", "title": "no date"}`
+
+	tests := []struct {
+		name     string
+		batch    string
+		ids      []string
+		failures []int
+		err      string
+	}{
+		{name: "array", batch: "[" + compact("org1.example.com/a") + ", " + compact("org1.example.com/b") + "]", ids: []string{"org1.example.com/a", "org1.example.com/b"}},
+		{name: "ndjson", batch: compact("org1.example.com/a") + "\n\n" + compact("org1.example.com/b") + "\n", ids: []string{"org1.example.com/a", "org1.example.com/b"}},
+		{name: "invalid item", batch: compact("org1.example.com/a") + "\n" + invalid, failures: []int{1}},
+		{name: "duplicate", batch: compact("org1.example.com/a") + "\n" + compact("org1.example.com/b") + "\n" + compact("org1.example.com/a"), failures: []int{2}},
+		{name: "undecodable", batch: `[{"id": 1}, ` + compact("org1.example.com/a") + "]", failures: []int{0}},
+		{name: "empty", batch: " ", err: "Batch is empty."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newTestStub()
+			l := new(DatasetMetadataLedger)
+			transient := registerTransient(t, "", "")
+			transient["metadata"] = []byte(tt.batch)
+
+			results, err := l.RegisterBatch(as(t, stub, org1Registrar, transient))
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if tt.failures != nil {
+				var rejection *BatchRejection
+				if !errors.As(err, &rejection) {
+					t.Fatalf("expected a batch rejection, got %v", err)
+				}
+				if len(rejection.Failures) != len(tt.failures) {
+					t.Fatalf("failures = %+v, want items %v", rejection.Failures, tt.failures)
+				}
+				for i, index := range tt.failures {
+					if rejection.Failures[i].Index != index || rejection.Failures[i].Error == "" {
+						t.Errorf("failure %d = %+v, want item %d", i, rejection.Failures[i], index)
+					}
+				}
+
+				// The message carries the failures for clients to decode
+				if !strings.HasPrefix(err.Error(), batchRejectedCode+": ") {
+					t.Fatalf("message %q lacks the %s code", err.Error(), batchRejectedCode)
+				}
+				decoded := new(BatchRejection)
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(err.Error(), batchRejectedCode+": ")), decoded); err != nil {
+					t.Fatalf("message does not decode: %v", err)
+				}
+				if decoded.Total != rejection.Total || len(decoded.Failures) != len(tt.failures) {
+					t.Errorf("decoded rejection = %+v", decoded)
+				}
+
+				// Valid items of a rejected batch are not registered either
+				if bs := stub.get("", "org1.example.com/a"); bs != nil {
+					t.Errorf("item of a rejected batch was registered")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RegisterBatch: %v", err)
+			}
+			if len(results) != len(tt.ids) {
+				t.Fatalf("results = %+v, want %v", results, tt.ids)
+			}
+			for i, id := range tt.ids {
+				if results[i].Index != i || results[i].ID != id || results[i].Revision != 1 || results[i].Error != "" {
+					t.Errorf("result %d = %+v", i, results[i])
+				}
+				if stub.get("", id) == nil {
+					t.Errorf("dataset %s not registered", id)
+				}
+			}
+		})
+	}
+}
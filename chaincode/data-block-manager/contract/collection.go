@@ -0,0 +1,430 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	collectionObjectType = "collection"
+	// pending change of the members of a collection, until every listed member agrees
+	collectionProposalObjectType = "collection~proposal"
+)
+
+// CollectionEntry records the member organisations of a named collection
+type CollectionEntry struct {
+	Name string `json:"name"`
+	// MSP IDs of the member organisations
+	Members   []string `json:"members"`
+	UpdatedBy string   `json:"updatedBy,omitempty"`
+	UpdatedAt string   `json:"updatedAt,omitempty"`
+}
+
+// CollectionProposal is a change of the members of a collection, stored once every current and
+// proposed member approved it
+type CollectionProposal struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+	// MSP IDs of the current and proposed members that approved the change
+	Approvals  []string `json:"approvals"`
+	ProposedBy string   `json:"proposedBy"`
+	ProposedAt string   `json:"proposedAt"`
+}
+
+// collectionDefinition is an entry of collection_config.json
+type collectionDefinition struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// e.g. "'Org1MSP.member'" in "OR('Org1MSP.member', 'Org2MSP.member')"
+var policyPrincipalPattern = regexp.MustCompile(`'([A-Za-z0-9._-]+)\.(?:member|peer|client|admin)'`)
+
+// configuredCollections maps the collections of collection_config.json to the MSP IDs named by their
+// policies. They are the only collections that can be registered, and their members apply until an
+// entry is stored on the ledger.
+var configuredCollections = map[string][]string{}
+
+// LoadCollectionConfig reads the collection configuration the chaincode is deployed with
+func LoadCollectionConfig(bs []byte) error {
+	var definitions []collectionDefinition
+	if err := json.Unmarshal(bs, &definitions); err != nil {
+		return fmt.Errorf("Failed to decode collection configuration.\n%v", err)
+	}
+
+	collections := map[string][]string{}
+	for _, d := range definitions {
+		members := []string{}
+		for _, match := range policyPrincipalPattern.FindAllStringSubmatch(d.Policy, -1) {
+			if !containsString(members, match[1]) {
+				members = append(members, match[1])
+			}
+		}
+		if d.Name == "" || len(members) == 0 {
+			return fmt.Errorf(`Collection "%s" of the collection configuration names no member in policy "%s".`, d.Name, d.Policy)
+		}
+		sort.Strings(members)
+		collections[d.Name] = members
+	}
+	configuredCollections = collections
+
+	return nil
+}
+
+func (c *CollectionEntry) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*c)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode collection entry to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (c *CollectionEntry) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, c)
+	if err != nil {
+		return fmt.Errorf("Failed to decode collection entry.\n%v", err)
+	}
+
+	return nil
+}
+
+func (p *CollectionProposal) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*p)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode collection proposal to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (p *CollectionProposal) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, p)
+	if err != nil {
+		return fmt.Errorf("Failed to decode collection proposal.\n%v", err)
+	}
+
+	return nil
+}
+
+func collectionKey(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	return createCompositeKey(ctx, collectionObjectType, name)
+}
+
+func collectionProposalKey(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	return createCompositeKey(ctx, collectionProposalObjectType, name)
+}
+
+// readCollectionProposal returns nil if no change of the collection is pending
+func readCollectionProposal(ctx contractapi.TransactionContextInterface, name string) (*CollectionProposal, error) {
+	key, err := collectionProposalKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil || bs == nil {
+		return nil, err
+	}
+
+	proposal := new(CollectionProposal)
+	if err := proposal.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// readCollection returns nil if the collection is neither stored nor configured
+func readCollection(ctx contractapi.TransactionContextInterface, name string) (*CollectionEntry, error) {
+	key, err := collectionKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		if members, ok := configuredCollections[name]; ok {
+			return &CollectionEntry{Name: name, Members: members}, nil
+		}
+		return nil, nil
+	}
+
+	entry := new(CollectionEntry)
+	if err := entry.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// requireCollectionMember checks that the organisation may write to the target. The public ledger
+// and the organisation's own implicit collection are always writable.
+func requireCollectionMember(ctx contractapi.TransactionContextInterface, mspID string, target string) error {
+	if target == "" || target == implicitPrivateDataCollection(mspID) {
+		return nil
+	}
+
+	entry, err := readCollection(ctx, target)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf(`Collection "%s" is not in the collection registry.`, target)
+	}
+	if !containsString(entry.Members, mspID) {
+		return fmt.Errorf(`Org "%s" is not a member of collection "%s".`, mspID, target)
+	}
+
+	return nil
+}
+
+// SetCollection proposes the member organisations of a collection defined in collection_config.json,
+// or approves the pending proposal with the same members. The members must all be named by the policy
+// of the collection. They are stored once every current and proposed member has approved them, so no
+// organisation can register a collection or remove other members on its own.
+func (l *DatasetMetadataLedger) SetCollection(ctx contractapi.TransactionContextInterface, name string, membersJSON string) (*CollectionProposal, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opSetCollection); err != nil {
+		return nil, err
+	}
+
+	configured, ok := configuredCollections[name]
+	if !ok {
+		return nil, fmt.Errorf(`Collection "%s" is not defined in the collection configuration.`, name)
+	}
+
+	var members []string
+	if err := json.Unmarshal([]byte(membersJSON), &members); err != nil {
+		return nil, fmt.Errorf("Failed to decode members : %v", err)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf(`Collection "%s" must have a member.`, name)
+	}
+	unique := []string{}
+	for _, member := range members {
+		if !containsString(configured, member) {
+			return nil, fmt.Errorf(`Org "%s" is not named by the policy of collection "%s".`, member, name)
+		}
+		if !containsString(unique, member) {
+			unique = append(unique, member)
+		}
+	}
+	members = unique
+	sort.Strings(members)
+
+	prev, err := readCollection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(prev.Members, ",") == strings.Join(members, ",") {
+		return nil, fmt.Errorf(`Collection "%s" already has members [%s].`, name, strings.Join(members, ", "))
+	}
+	// Current members agree to be removed, new members to be added
+	approvers := append([]string{}, members...)
+	for _, member := range prev.Members {
+		if !containsString(approvers, member) {
+			approvers = append(approvers, member)
+		}
+	}
+	if !containsString(approvers, mspID) {
+		return nil, fmt.Errorf(`Org "%s" is neither a current nor a proposed member of collection "%s".`, mspID, name)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A proposal of other members replaces the pending one, whose approvals are dropped
+	proposal, err := readCollectionProposal(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if proposal == nil || strings.Join(proposal.Members, ",") != strings.Join(members, ",") {
+		proposal = &CollectionProposal{Name: name, Members: members, ProposedBy: clientID, ProposedAt: ts}
+	}
+	if !containsString(proposal.Approvals, mspID) {
+		proposal.Approvals = append(proposal.Approvals, mspID)
+		sort.Strings(proposal.Approvals)
+	}
+
+	proposalKey, err := collectionProposalKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	approved := true
+	for _, approver := range approvers {
+		if !containsString(proposal.Approvals, approver) {
+			approved = false
+		}
+	}
+	if !approved {
+		bs, err := proposal.ToBytes()
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.GetStub().PutState(proposalKey, bs); err != nil {
+			return nil, fmt.Errorf(`Failed to write proposal of collection "%s" : %v`, name, err)
+		}
+		return proposal, nil
+	}
+
+	entry := &CollectionEntry{Name: name, Members: members, UpdatedBy: clientID, UpdatedAt: ts}
+	bs, err := entry.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := collectionKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to write collection "%s" : %v`, name, err)
+	}
+	if err := ctx.GetStub().DelState(proposalKey); err != nil {
+		return nil, fmt.Errorf(`Failed to delete proposal of collection "%s" : %v`, name, err)
+	}
+
+	return proposal, nil
+}
+
+// GetCollectionProposal returns the pending change of the members of a collection
+func (l *DatasetMetadataLedger) GetCollectionProposal(ctx contractapi.TransactionContextInterface, name string) (*CollectionProposal, error) {
+	proposal, err := readCollectionProposal(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if proposal == nil {
+		return nil, fmt.Errorf(`No change of collection "%s" is pending.`, name)
+	}
+
+	return proposal, nil
+}
+
+// GetCollection returns the member organisations of a registered collection
+func (l *DatasetMetadataLedger) GetCollection(ctx contractapi.TransactionContextInterface, name string) (*CollectionEntry, error) {
+	entry, err := readCollection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf(`Collection "%s" is not in the collection registry.`, name)
+	}
+
+	return entry, nil
+}
+
+// ShareWith publishes a dataset of the client's organisation into one more collection, following the
+// disclosure policy of the organisation
+func (l *DatasetMetadataLedger) ShareWith(ctx contractapi.TransactionContextInterface, datasetID string, collection string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opUpdate); err != nil {
+		return err
+	}
+
+	implicitCollection := implicitPrivateDataCollection(mspID)
+	if err := requireNotRetired(ctx, implicitCollection, datasetID); err != nil {
+		return err
+	}
+	if err := requireNotExpired(ctx, implicitCollection, datasetID); err != nil {
+		return err
+	}
+
+	reg, err := readRegistration(ctx, mspID, datasetID)
+	if err != nil {
+		return err
+	}
+	if reg == nil {
+		return fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return err
+	}
+	if containsString(reg.Collections, collection) {
+		return fmt.Errorf(`Dataset "%s" is already shared with collection "%s".`, datasetID, collection)
+	}
+	if err := requireCollectionMember(ctx, mspID, collection); err != nil {
+		return err
+	}
+	if err := requireNotRetired(ctx, collection, datasetID); err != nil {
+		return err
+	}
+
+	mdAsBytes, err := readFromCollection(ctx, implicitCollection, datasetID)
+	if err != nil {
+		return err
+	}
+	md := new(DatasetMetadata)
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return err
+	}
+	policy, err := readDisclosurePolicy(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if err := publishProjections(ctx, policy, []string{collection}, md); err != nil {
+		return err
+	}
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	reg.Collections = append(reg.Collections, collection)
+	reg.UpdatedAt = ts
+	if err := writeRetention(ctx, reg); err != nil {
+		return err
+	}
+	if err := writeRegistration(ctx, reg); err != nil {
+		return err
+	}
+
+	return emitDatasetEvent(ctx, eventDatasetShared, &DatasetEvent{
+		ID:          datasetID,
+		Owner:       mspID,
+		Collections: []string{collection},
+		Revision:    reg.Revision,
+	})
+}
+
+// ListSharedCollections returns the collections holding a copy of a dataset of the client's
+// organisation, where "" stands for the public ledger
+func (l *DatasetMetadataLedger) ListSharedCollections(ctx contractapi.TransactionContextInterface, datasetID string) ([]string, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryPrivate); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, mspID)
+	}
+
+	return reg.Collections, nil
+}
@@ -0,0 +1,134 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadCollectionConfig(t *testing.T) {
+	members := configuredCollections["publicDataBlockCollection"]
+	if strings.Join(members, ",") != "Org1MSP,Org2MSP" {
+		t.Errorf("members of publicDataBlockCollection = %v", members)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "not JSON", config: `{`, err: "Failed to decode collection configuration"},
+		{name: "no member", config: `[{"name": "c", "policy": "OR()"}]`, err: `Collection "c" of the collection configuration names no member`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, LoadCollectionConfig([]byte(tt.config)), tt.err)
+			if _, ok := configuredCollections["publicDataBlockCollection"]; !ok {
+				t.Error("configuration replaced by an invalid one")
+			}
+		})
+	}
+}
+
+func TestSetCollection(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const collection = "publicDataBlockCollection"
+
+	tests := []struct {
+		name       string
+		id         *testIdentity
+		collection string
+		members    string
+		err        string
+	}{
+		{name: "not configured", id: org1Admin, collection: "org1Only", members: `["Org1MSP"]`, err: `Collection "org1Only" is not defined in the collection configuration`},
+		{name: "implicit collection", id: org1Admin, collection: implicitPrivateDataCollection("Org1MSP"), members: `["Org1MSP"]`, err: "is not defined in the collection configuration"},
+		{name: "member outside policy", id: org1Admin, collection: collection, members: `["Org1MSP", "Org3MSP"]`, err: `Org "Org3MSP" is not named by the policy of collection`},
+		{name: "no member", id: org1Admin, collection: collection, members: `[]`, err: "must have a member"},
+		{name: "unchanged", id: org1Admin, collection: collection, members: `["Org2MSP", "Org1MSP"]`, err: "already has members [Org1MSP, Org2MSP]"},
+		{name: "registrar", id: org1Registrar, collection: collection, members: `["Org1MSP"]`, err: `is denied "SetCollection"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := l.SetCollection(as(t, stub, tt.id, nil), tt.collection, tt.members)
+			requireError(t, err, tt.err)
+		})
+	}
+
+	t.Run("member removed with its approval only", func(t *testing.T) {
+		proposal, err := l.SetCollection(as(t, stub, org1Admin, nil), collection, `["Org1MSP"]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(proposal.Approvals, ",") != "Org1MSP" {
+			t.Errorf("approvals = %v", proposal.Approvals)
+		}
+		entry, err := l.GetCollection(as(t, stub, org2Reader, nil), collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entry.Members) != 2 {
+			t.Fatalf("members changed to %v before Org2MSP approved", entry.Members)
+		}
+		if err := requireCollectionMember(as(t, stub, org2Registrar, nil), "Org2MSP", collection); err != nil {
+			t.Fatalf("Org2MSP lost access before approving: %v", err)
+		}
+
+		// A competing proposal drops the approvals of the pending one
+		proposal, err = l.SetCollection(as(t, stub, org2Admin, nil), collection, `["Org2MSP"]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(proposal.Approvals, ",") != "Org2MSP" {
+			t.Errorf("approvals = %v", proposal.Approvals)
+		}
+
+		if _, err := l.SetCollection(as(t, stub, org1Admin, nil), collection, `["Org1MSP"]`); err != nil {
+			t.Fatal(err)
+		}
+		pending, err := l.GetCollectionProposal(as(t, stub, org2Reader, nil), collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(pending.Members, ",") != "Org1MSP" {
+			t.Errorf("pending members = %v", pending.Members)
+		}
+
+		proposal, err = l.SetCollection(as(t, stub, org2Admin, nil), collection, `["Org1MSP"]`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(proposal.Approvals, ",") != "Org1MSP,Org2MSP" {
+			t.Errorf("approvals = %v", proposal.Approvals)
+		}
+		entry, err = l.GetCollection(as(t, stub, org2Reader, nil), collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(entry.Members, ",") != "Org1MSP" {
+			t.Errorf("members = %v, want [Org1MSP]", entry.Members)
+		}
+		requireError(t, requireCollectionMember(as(t, stub, org2Registrar, nil), "Org2MSP", collection), `Org "Org2MSP" is not a member of collection`)
+		_, err = l.GetCollectionProposal(as(t, stub, org2Reader, nil), collection)
+		requireError(t, err, "No change of collection")
+	})
+
+	t.Run("member added with its approval only", func(t *testing.T) {
+		if _, err := l.SetCollection(as(t, stub, org1Admin, nil), collection, `["Org1MSP", "Org2MSP"]`); err != nil {
+			t.Fatal(err)
+		}
+		entry, err := l.GetCollection(as(t, stub, org1Reader, nil), collection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entry.Members) != 1 {
+			t.Fatalf("members changed to %v before Org2MSP approved", entry.Members)
+		}
+		if _, err := l.SetCollection(as(t, stub, org2Admin, nil), collection, `["Org1MSP", "Org2MSP"]`); err != nil {
+			t.Fatal(err)
+		}
+		if err := requireCollectionMember(as(t, stub, org2Registrar, nil), "Org2MSP", collection); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -24,48 +24,73 @@ func readFromCollection(ctx contractapi.TransactionContextInterface, collection
 	return bs, nil
 }
 
-func readFromPublicByRange(ctx contractapi.TransactionContextInterface, start string, end string, max int) ([][]byte, error) {
-	result := [][]byte{}
-	counter := 0
+// readFromTarget reads from the target, where "" stands for the public ledger
+func readFromTarget(ctx contractapi.TransactionContextInterface, target string, key string) ([]byte, error) {
+	if target != "" {
+		return readFromCollection(ctx, target, key)
+	}
+	return readFromPublic(ctx, key)
+}
+
+// keyValue is a state entry read by range
+type keyValue struct {
+	Key   string
+	Value []byte
+}
 
-	it, err := ctx.GetStub().GetStateByRange(start, end)
+// readFromPublicByRange reads one page of the range, continuing after the bookmark of the previous page
+func readFromPublicByRange(ctx contractapi.TransactionContextInterface, start string, end string, pageSize int, bookmark string) ([]*keyValue, string, error) {
+	result := []*keyValue{}
+
+	it, responseMetadata, err := ctx.GetStub().GetStateByRangeWithPagination(start, end, int32(pageSize), bookmark)
 	if err != nil {
-		return nil, fmt.Errorf(`Failed to read from the public ledger by range "%s" -> "%s" : %v`, start, end, err)
+		return nil, "", fmt.Errorf(`Failed to read from the public ledger by range "%s" -> "%s" : %v`, start, end, err)
 	}
 	defer it.Close()
 
-	for it.HasNext() && counter < max {
+	for it.HasNext() {
 		item, err := it.Next()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		result = append(result, item.Value)
-		counter++
+		result = append(result, &keyValue{Key: item.Key, Value: item.Value})
 	}
 
-	return result, nil
+	return result, responseMetadata.Bookmark, nil
 }
 
-func readFromCollectionByRange(ctx contractapi.TransactionContextInterface, collection string, start string, end string, max int) ([][]byte, error) {
-	result := [][]byte{}
-	counter := 0
+// readFromCollectionByRange reads one page of the range. Private data has no native pagination, so
+// the bookmark is the key to resume from, and it is empty once the range is exhausted.
+func readFromCollectionByRange(ctx contractapi.TransactionContextInterface, collection string, start string, end string, pageSize int, bookmark string) ([]*keyValue, string, error) {
+	result := []*keyValue{}
+	if bookmark != "" {
+		start = bookmark
+	}
 
 	it, err := ctx.GetStub().GetPrivateDataByRange(collection, start, end)
 	if err != nil {
-		return nil, fmt.Errorf(`Failed to read from collection "%s" by range "%s" -> "%s" : %v`, collection, start, end, err)
+		return nil, "", fmt.Errorf(`Failed to read from collection "%s" by range "%s" -> "%s" : %v`, collection, start, end, err)
 	}
 	defer it.Close()
 
-	for it.HasNext() && counter < max {
+	for it.HasNext() && len(result) < pageSize {
+		item, err := it.Next()
+		if err != nil {
+			return nil, "", err
+		}
+		result = append(result, &keyValue{Key: item.Key, Value: item.Value})
+	}
+
+	next := ""
+	if it.HasNext() {
 		item, err := it.Next()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		result = append(result, item.Value)
-		counter++
+		next = item.Key
 	}
 
-	return result, nil
+	return result, next, nil
 }
 
 func createFromPublic(ctx contractapi.TransactionContextInterface, key string, value []byte) error {
@@ -100,6 +125,38 @@ func createFromCollection(ctx contractapi.TransactionContextInterface, collectio
 	return nil
 }
 
+func updateFromPublic(ctx contractapi.TransactionContextInterface, key string, value []byte) error {
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return err
+	}
+	if bs == nil {
+		return fmt.Errorf(`Failed to update from the public ledger : key does not exist "%s"`, key)
+	}
+
+	if err := ctx.GetStub().PutState(key, value); err != nil {
+		return fmt.Errorf(`Failed to update from the public ledger with key "%s" : %v`, key, err)
+	}
+
+	return nil
+}
+
+func updateFromCollection(ctx contractapi.TransactionContextInterface, collection string, key string, value []byte) error {
+	bs, err := readFromCollection(ctx, collection, key)
+	if err != nil {
+		return err
+	}
+	if bs == nil {
+		return fmt.Errorf(`Failed to update from collection "%s" : key does not exist "%s"`, collection, key)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(collection, key, value); err != nil {
+		return fmt.Errorf(`Failed to write to collection "%s" with key "%s" : %v`, collection, key, err)
+	}
+
+	return nil
+}
+
 func deleteFromPublic(ctx contractapi.TransactionContextInterface, key string) error {
 	err := ctx.GetStub().DelState(key)
 	if err != nil {
@@ -118,72 +175,53 @@ func deleteFromCollection(ctx contractapi.TransactionContextInterface, collectio
 	return nil
 }
 
-// func (l *DataBlockLedger) read_data_block(ctx contractapi.TransactionContextInterface, collection string, key string, block DataBlockInterface) error {
-// 	bs, err := l.check_data_block_exists(ctx, collection, key)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return block.from_bytes(key, bs)
-// }
-
-// func (l *DataBlockLedger) read_data_block_private(ctx contractapi.TransactionContextInterface, collection string, key string) (*DataBlockPrivate, error) {
-// 	bs, err := l.check_data_block_exists(ctx, collection, key)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	block := new(DataBlockPrivate)
-// 	err = block.from_bytes(key, bs)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return block, nil
-// }
-
-// func (l *DataBlockLedger) read_data_block_by_range(ctx contractapi.TransactionContextInterface, collection string, start string, end string, max int) error {
-// 	map_, err := l.get_by_range(ctx, collection, start, end, max)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return nil
-// }
-
-// func (l *DataBlockLedger) create_data_block(ctx contractapi.TransactionContextInterface, collection string, key string, block DataBlockInterface) error {
-// 	err := l.check_data_block_not_exists(ctx, collection, key)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	bs, err := block.to_bytes()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return l.set(ctx, collection, key, bs)
-// }
-
-// func (l *DataBlockLedger) update_data_block(ctx contractapi.TransactionContextInterface, collection string, key string, block DataBlockInterface) error {
-// 	_, err := l.check_data_block_exists(ctx, collection, key)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	bs, err := block.to_bytes()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return l.set(ctx, collection, key, bs)
-// }
-
-// func (l *DataBlockLedger) delete_data_block(ctx contractapi.TransactionContextInterface, collection, key string) error {
-// 	_, err := l.check_data_block_exists(ctx, collection, key)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return l.del(ctx, collection, key)
-// }
+// createFromTargets writes the value to every target, where "" stands for the public ledger
+func createFromTargets(ctx contractapi.TransactionContextInterface, targets []string, key string, value []byte) error {
+	for _, target := range targets {
+		if target != "" {
+			if err := createFromCollection(ctx, target, key, value); err != nil {
+				return err
+			}
+		} else {
+			if err := createFromPublic(ctx, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateFromTargets overwrites the value in every target, where "" stands for the public ledger
+func updateFromTargets(ctx contractapi.TransactionContextInterface, targets []string, key string, value []byte) error {
+	for _, target := range targets {
+		if target != "" {
+			if err := updateFromCollection(ctx, target, key, value); err != nil {
+				return err
+			}
+		} else {
+			if err := updateFromPublic(ctx, key, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deleteFromTargets removes the key from every target, where "" stands for the public ledger
+func deleteFromTargets(ctx contractapi.TransactionContextInterface, targets []string, key string) error {
+	for _, target := range targets {
+		if target != "" {
+			if err := deleteFromCollection(ctx, target, key); err != nil {
+				return err
+			}
+		} else {
+			if err := deleteFromPublic(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
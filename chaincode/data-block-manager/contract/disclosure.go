@@ -0,0 +1,344 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const disclosureObjectType = "disclosure"
+
+// DisclosurePolicy lists the metadata fields an organisation writes to each collection
+type DisclosurePolicy struct {
+	Owner string `json:"owner"`
+	// Fields disclosed to collections not listed below
+	Default []string `json:"default"`
+	// Fields disclosed per collection, where "" stands for the public ledger
+	Collections map[string][]string `json:"collections,omitempty"`
+}
+
+// DatasetProjection is the set of fields a collection holds for a dataset
+type DatasetProjection struct {
+	ID         string   `json:"id"`
+	Collection string   `json:"collection"`
+	Fields     []string `json:"fields"`
+}
+
+// defaultDisclosedFields apply until the organisation stores a disclosure policy. They include the
+// catalog facets, as the catalog indexes of a collection only cover the fields disclosed to it, and
+// PII columns are redacted from every projection regardless.
+var defaultDisclosedFields = []string{
+	"id", "name", "note", "title", "description", "containsSubnationalData", "source",
+	"organisation", "maintainer", "date", "location", "fieldNames", "fileTypes",
+	"numberOfRows", "license", "defineLicense", "methodology", "defineMethodology",
+	"updateFrequency", "comments", "tags", "columns", "content",
+}
+
+// metadataFields returns the JSON field names of DatasetMetadata
+func metadataFields() []string {
+	t := reflect.TypeOf(DatasetMetadata{})
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func (p *DisclosurePolicy) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*p)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode disclosure policy to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (p *DisclosurePolicy) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, p)
+	if err != nil {
+		return fmt.Errorf("Failed to decode disclosure policy.\n%v", err)
+	}
+
+	return nil
+}
+
+func (p *DisclosurePolicy) Validate() error {
+	known := metadataFields()
+	check := func(collection string, fields []string) error {
+		for _, field := range fields {
+			if !containsString(known, field) {
+				return fmt.Errorf(`Unknown field "%s" disclosed to collection "%s".`, field, collection)
+			}
+			// the endpoint is only released through access grants once terms are accepted
+			if field == "endpoint" {
+				return fmt.Errorf(`Field "endpoint" cannot be disclosed to collection "%s".`, collection)
+			}
+		}
+		return nil
+	}
+
+	if err := check("default", p.Default); err != nil {
+		return err
+	}
+	for collection, fields := range p.Collections {
+		if err := check(collection, fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FieldsFor returns the fields disclosed to the target, always including the ID
+func (p *DisclosurePolicy) FieldsFor(target string) []string {
+	fields, ok := p.Collections[target]
+	if !ok {
+		fields = p.Default
+	}
+
+	result := []string{"id"}
+	for _, field := range fields {
+		if !containsString(result, field) {
+			result = append(result, field)
+		}
+	}
+	sort.Strings(result[1:])
+
+	return result
+}
+
+// project keeps the disclosed fields of the metadata only
+func (p *DisclosurePolicy) project(md *DatasetMetadata, target string) (*DatasetMetadata, error) {
+	bs, err := md.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &fields); err != nil {
+		return nil, fmt.Errorf("Failed to project metadata.\n%v", err)
+	}
+	disclosed := p.FieldsFor(target)
+	for name := range fields {
+		if !containsString(disclosed, name) {
+			delete(fields, name)
+		}
+	}
+
+	bs, err = json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to project metadata.\n%v", err)
+	}
+	projection := new(DatasetMetadata)
+	if err := projection.FromBytes(bs); err != nil {
+		return nil, err
+	}
+	redactColumns(projection, md.Columns)
+
+	return projection, nil
+}
+
+// redactColumns removes the PII columns of the schema, and their names, from the projection
+func redactColumns(projection *DatasetMetadata, columns []ColumnSchema) {
+	pii := []string{}
+	for _, c := range columns {
+		if c.PII {
+			pii = append(pii, c.Name)
+		}
+	}
+	if len(pii) == 0 {
+		return
+	}
+
+	var fieldNames []string
+	for _, name := range projection.FieldNames {
+		if !containsString(pii, name) {
+			fieldNames = append(fieldNames, name)
+		}
+	}
+	projection.FieldNames = fieldNames
+	var redacted []ColumnSchema
+	for _, c := range projection.Columns {
+		if !c.PII {
+			redacted = append(redacted, c)
+		}
+	}
+	projection.Columns = redacted
+}
+
+func disclosureKey(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	return createCompositeKey(ctx, disclosureObjectType, mspID)
+}
+
+// readDisclosurePolicy falls back to the default fields if the organisation has not stored a policy
+func readDisclosurePolicy(ctx contractapi.TransactionContextInterface, mspID string) (*DisclosurePolicy, error) {
+	key, err := disclosureKey(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return &DisclosurePolicy{Owner: mspID, Default: defaultDisclosedFields}, nil
+	}
+
+	policy := new(DisclosurePolicy)
+	if err := policy.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// SetDisclosurePolicy stores the fields the client's organisation discloses to each collection.
+// It applies to datasets registered or updated afterwards.
+func (l *DatasetMetadataLedger) SetDisclosurePolicy(ctx contractapi.TransactionContextInterface, policyJSON string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opSetDisclosurePolicy); err != nil {
+		return err
+	}
+
+	policy := new(DisclosurePolicy)
+	if err := policy.FromBytes([]byte(policyJSON)); err != nil {
+		return err
+	}
+	policy.Owner = mspID
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	bs, err := policy.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	key, err := disclosureKey(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return fmt.Errorf(`Failed to write disclosure policy of Org "%s" : %v`, mspID, err)
+	}
+
+	return nil
+}
+
+// GetDisclosurePolicy returns the effective disclosure policy of an organisation
+func (l *DatasetMetadataLedger) GetDisclosurePolicy(ctx contractapi.TransactionContextInterface, mspID string) (*DisclosurePolicy, error) {
+	return readDisclosurePolicy(ctx, mspID)
+}
+
+// QueryProjection returns the fields each collection holds for a dataset of the client's organisation
+func (l *DatasetMetadataLedger) QueryProjection(ctx contractapi.TransactionContextInterface, key string) ([]*DatasetProjection, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryPrivate); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, key)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, key, mspID)
+	}
+
+	policy, err := readDisclosurePolicy(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*DatasetProjection{}
+	for _, target := range reg.Collections {
+		result = append(result, &DatasetProjection{ID: key, Collection: target, Fields: policy.FieldsFor(target)})
+	}
+
+	return result, nil
+}
+
+// publishProjections writes the projection of the metadata, with its catalog indexes, to every target
+func publishProjections(ctx contractapi.TransactionContextInterface, policy *DisclosurePolicy, targets []string, md *DatasetMetadata) error {
+	for _, target := range targets {
+		projection, err := policy.project(md, target)
+		if err != nil {
+			return err
+		}
+		bs, err := projection.ToBytes()
+		if err != nil {
+			return err
+		}
+		if err := createFromTargets(ctx, []string{target}, md.ID, bs); err != nil {
+			return err
+		}
+		if err := createCatalogIndexes(ctx, target, bs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// republishProjections overwrites the projection in every target and replaces the indexes of the previous one
+func republishProjections(ctx contractapi.TransactionContextInterface, policy *DisclosurePolicy, targets []string, md *DatasetMetadata) error {
+	for _, target := range targets {
+		prevAsBytes, err := readFromTarget(ctx, target, md.ID)
+		if err != nil {
+			return err
+		}
+
+		projection, err := policy.project(md, target)
+		if err != nil {
+			return err
+		}
+		bs, err := projection.ToBytes()
+		if err != nil {
+			return err
+		}
+		if err := updateFromTargets(ctx, []string{target}, md.ID, bs); err != nil {
+			return err
+		}
+		if err := updateCatalogIndexes(ctx, target, prevAsBytes, bs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withdrawProjections removes the projection and its catalog indexes from every target
+func withdrawProjections(ctx contractapi.TransactionContextInterface, targets []string, id string) error {
+	for _, target := range targets {
+		prevAsBytes, err := readFromTarget(ctx, target, id)
+		if err != nil {
+			return err
+		}
+		if prevAsBytes == nil {
+			continue
+		}
+
+		if err := deleteCatalogIndexes(ctx, target, prevAsBytes); err != nil {
+			return err
+		}
+		if err := deleteFromTargets(ctx, []string{target}, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
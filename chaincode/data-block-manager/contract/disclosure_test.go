@@ -0,0 +1,164 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestDefaultPolicyKeepsCatalogFacets(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	tests := []struct {
+		name  string
+		query func(ctx contractapi.TransactionContextInterface) ([]*DatasetMetadataPublic, error)
+	}{
+		{name: "tag", query: func(ctx contractapi.TransactionContextInterface) ([]*DatasetMetadataPublic, error) {
+			return l.QueryByTag(ctx, "", "T1", 10)
+		}},
+		{name: "file type", query: func(ctx contractapi.TransactionContextInterface) ([]*DatasetMetadataPublic, error) {
+			return l.QueryByFileType(ctx, "", "csv", 10)
+		}},
+		{name: "field", query: func(ctx contractapi.TransactionContextInterface) ([]*DatasetMetadataPublic, error) {
+			return l.QueryByField(ctx, "", "x2", 10)
+		}},
+		{name: "organisation", query: func(ctx contractapi.TransactionContextInterface) ([]*DatasetMetadataPublic, error) {
+			return l.QueryByOrganisation(ctx, "", "org1.example.com", 10)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.query(as(t, stub, org2Reader, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result) != 1 || result[0].ID != exampleID {
+				t.Fatalf("found %d datasets, want %s", len(result), exampleID)
+			}
+			if result[0].Endpoint != "" {
+				t.Errorf("endpoint %q disclosed", result[0].Endpoint)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	md := &DatasetMetadata{
+		ID:         exampleID,
+		Title:      "Patients",
+		Maintainer: "root@org1.example.com",
+		FieldNames: []string{"name", "age", "ward"},
+		Columns: []ColumnSchema{
+			{Name: "name", Type: "string", PII: true},
+			{Name: "age", Type: "integer"},
+			{Name: "ward", Type: "string"},
+		},
+		Endpoint: "https://api.org1.example.com",
+	}
+	policy := &DisclosurePolicy{
+		Owner:   "Org1MSP",
+		Default: []string{"title", "fieldNames", "columns"},
+		Collections: map[string][]string{
+			"":         {"title"},
+			"internal": {"title", "maintainer", "fieldNames"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		title      string
+		maintainer string
+		fieldNames []string
+		columns    []string
+	}{
+		{name: "public ledger", target: "", title: "Patients"},
+		{name: "listed collection", target: "internal", title: "Patients", maintainer: "root@org1.example.com", fieldNames: []string{"age", "ward"}},
+		{name: "default", target: "other", title: "Patients", fieldNames: []string{"age", "ward"}, columns: []string{"age", "ward"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projection, err := policy.project(md, tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if projection.ID != exampleID {
+				t.Errorf("id = %q, want %q", projection.ID, exampleID)
+			}
+			if projection.Title != tt.title || projection.Maintainer != tt.maintainer {
+				t.Errorf("title, maintainer = %q, %q, want %q, %q", projection.Title, projection.Maintainer, tt.title, tt.maintainer)
+			}
+			if projection.Endpoint != "" {
+				t.Errorf("endpoint %q disclosed", projection.Endpoint)
+			}
+			if strings.Join(projection.FieldNames, " ") != strings.Join(tt.fieldNames, " ") {
+				t.Errorf("fieldNames = %v, want %v", projection.FieldNames, tt.fieldNames)
+			}
+			columns := []string{}
+			for _, c := range projection.Columns {
+				columns = append(columns, c.Name)
+			}
+			if strings.Join(columns, " ") != strings.Join(tt.columns, " ") {
+				t.Errorf("columns = %v, want %v", columns, tt.columns)
+			}
+		})
+	}
+
+	t.Run("source untouched", func(t *testing.T) {
+		if len(md.FieldNames) != 3 || len(md.Columns) != 3 || md.Endpoint == "" {
+			t.Errorf("metadata modified by projection: %+v", md)
+		}
+	})
+}
+
+func TestDisclosurePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy DisclosurePolicy
+		err    string
+	}{
+		{name: "valid", policy: DisclosurePolicy{Default: []string{"title"}, Collections: map[string][]string{"": {"title", "tags"}}}},
+		{name: "unknown default field", policy: DisclosurePolicy{Default: []string{"owner"}}, err: `Unknown field "owner" disclosed to collection "default"`},
+		{name: "unknown collection field", policy: DisclosurePolicy{Collections: map[string][]string{"c": {"titel"}}}, err: `Unknown field "titel" disclosed to collection "c"`},
+		{name: "endpoint", policy: DisclosurePolicy{Collections: map[string][]string{"": {"endpoint"}}}, err: `Field "endpoint" cannot be disclosed to collection ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPublishedCopiesAreRedacted(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	metadata := strings.Replace(exampleMetadata, `"fieldNames": ["x1", "x2"],`,
+		`"fieldNames": ["x1", "x2"], "columns": [{"name": "x1", "type": "string", "pii": true}, {"name": "x2", "type": "integer"}],`, 1)
+	mustRegister(t, stub, org1Registrar, metadata, "")
+
+	public, err := l.Query(as(t, stub, org2Reader, nil), "", exampleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(public.FieldNames) != 1 || public.FieldNames[0] != "x2" || len(public.Columns) != 1 || public.Columns[0].Name != "x2" {
+		t.Errorf("public copy has fieldNames %v and columns %+v", public.FieldNames, public.Columns)
+	}
+
+	private, err := l.QueryPrivate(as(t, stub, org1Registrar, nil), exampleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(private.FieldNames) != 2 || len(private.Columns) != 2 {
+		t.Errorf("private copy has fieldNames %v and columns %+v", private.FieldNames, private.Columns)
+	}
+}
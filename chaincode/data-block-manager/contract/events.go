@@ -0,0 +1,57 @@
+package contract
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// names of the chaincode events emitted on dataset state changes
+const (
+	eventDatasetRegistered = "DatasetRegistered"
+	eventDatasetUpdated    = "DatasetUpdated"
+	eventDatasetRetired    = "DatasetRetired"
+	eventDatasetShared     = "DatasetShared"
+	eventAccessRequested   = "AccessRequested"
+	eventAccessGranted     = "AccessGranted"
+	eventAccessDenied      = "AccessDenied"
+	eventAccessRevoked     = "AccessRevoked"
+	eventTermsAccepted     = "TermsAccepted"
+
+	// a transaction carries a single event, so a batch is announced at once
+	eventDatasetsRegistered = "DatasetsRegistered"
+	eventDatasetsExpired    = "DatasetsExpired"
+)
+
+// DatasetEvent is the payload of every dataset event, it never carries private metadata fields
+type DatasetEvent struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	// Set instead of ID on batch events
+	IDs []string `json:"ids,omitempty"`
+	// MSP ID of the owning organisation
+	Owner string `json:"owner"`
+	// Collections affected by the change, where "" stands for the public ledger
+	Collections []string `json:"collections"`
+	Revision    int      `json:"revision,omitempty"`
+	// Set on access events
+	RequestID string `json:"requestID,omitempty"`
+	Requester string `json:"requester,omitempty"`
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+}
+
+// emitDatasetEvent stamps the event with the transaction and sets it as the chaincode event
+func emitDatasetEvent(ctx contractapi.TransactionContextInterface, eventType string, ev *DatasetEvent) error {
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	ev.Type = eventType
+	ev.TxID = ctx.GetStub().GetTxID()
+	ev.Timestamp = ts
+	if ev.Collections == nil {
+		ev.Collections = []string{}
+	}
+
+	return emitEvent(ctx, eventType, ev)
+}
@@ -0,0 +1,116 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatasetEvents(t *testing.T) {
+	const collection = "publicDataBlockCollection"
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	compact := func(id string) string {
+		return strings.Join(strings.Fields(metadataWithID(id)), " ")
+	}
+	var requestID string
+	request := func() error {
+		req, err := l.RequestAccess(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", "research")
+		if err == nil {
+			requestID = req.ID
+		}
+		return err
+	}
+
+	steps := []struct {
+		name  string
+		run   func() error
+		event string
+		ids   []string
+	}{
+		{name: "register", event: eventDatasetRegistered, ids: []string{exampleID}, run: func() error {
+			return registerWithRetention(t, stub, exampleMetadata, `{"class":"short"}`, "")
+		}},
+		{name: "update", event: eventDatasetUpdated, ids: []string{exampleID}, run: func() error {
+			updated := strings.Replace(exampleMetadata, "Org1's example dataset", "Updated title", 1)
+			return l.Update(as(t, stub, org1Registrar, map[string][]byte{"metadata": []byte(updated)}))
+		}},
+		{name: "share", event: eventDatasetShared, ids: []string{exampleID}, run: func() error {
+			return l.ShareWith(as(t, stub, org1Registrar, nil), exampleID, collection)
+		}},
+		{name: "accept terms", event: eventTermsAccepted, ids: []string{exampleID}, run: func() error {
+			acceptExampleTerms(t, stub)
+			return nil
+		}},
+		{name: "request access", event: eventAccessRequested, ids: []string{exampleID}, run: request},
+		{name: "grant access", event: eventAccessGranted, ids: []string{exampleID}, run: func() error {
+			return l.GrantAccess(as(t, stub, org1Registrar, nil), exampleID, requestID)
+		}},
+		{name: "revoke access", event: eventAccessRevoked, ids: []string{exampleID}, run: func() error {
+			return l.RevokeAccess(as(t, stub, org1Registrar, nil), exampleID, requestID, "done")
+		}},
+		{name: "deny access", event: eventAccessDenied, ids: []string{exampleID}, run: func() error {
+			if err := request(); err != nil {
+				return err
+			}
+			return l.DenyAccess(as(t, stub, org1Registrar, nil), exampleID, requestID, "no")
+		}},
+		{name: "register batch", event: eventDatasetsRegistered, ids: []string{"org1.example.com/a", "org1.example.com/b"}, run: func() error {
+			transient := registerTransient(t, "", "")
+			transient["metadata"] = []byte(compact("org1.example.com/a") + "\n" + compact("org1.example.com/b"))
+			_, err := l.RegisterBatch(as(t, stub, org1Registrar, transient))
+			return err
+		}},
+		{name: "deregister", event: eventDatasetRetired, ids: []string{"org1.example.com/a"}, run: func() error {
+			return l.Deregister(as(t, stub, org1Registrar, nil), "org1.example.com/a", "test")
+		}},
+		{name: "purge", event: eventDatasetsExpired, ids: []string{exampleID}, run: func() error {
+			stub.txTime = stub.txTime.Add(31 * 24 * time.Hour)
+			_, err := l.PurgeExpired(as(t, stub, org1Registrar, nil), 10)
+			return err
+		}},
+	}
+
+	// fields of DatasetEvent, none of which is private
+	allowed := map[string]bool{
+		"type": true, "id": true, "ids": true, "owner": true, "collections": true, "revision": true,
+		"requestID": true, "requester": true, "txID": true, "timestamp": true,
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		payload, ok := stub.events[step.event]
+		if !ok || len(stub.events) != 1 {
+			t.Fatalf("%s: events = %v, want only %s", step.name, stub.events, step.event)
+		}
+
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		for field := range fields {
+			if !allowed[field] {
+				t.Errorf("%s: payload carries field %q", step.name, field)
+			}
+		}
+		for _, private := range []string{"https://api.org1.example.com", "research", "allowedPurposes"} {
+			if strings.Contains(string(payload), private) {
+				t.Errorf("%s: payload %s discloses %q", step.name, payload, private)
+			}
+		}
+
+		ev := new(DatasetEvent)
+		if err := json.Unmarshal(payload, ev); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		ids := ev.IDs
+		if ev.ID != "" {
+			ids = []string{ev.ID}
+		}
+		if ev.Type != step.event || ev.Owner != "Org1MSP" || ev.TxID != stub.txID || strings.Join(ids, ",") != strings.Join(step.ids, ",") {
+			t.Errorf("%s: event = %+v", step.name, ev)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// formats of ExportDataset
+const (
+	formatDCAT      = "dcat"
+	formatSchemaOrg = "schemaorg"
+)
+
+// licenseAliases maps the short Open Data Commons names accepted as license to SPDX identifiers
+var licenseAliases = map[string]string{
+	"ODC-PDDL": "PDDL-1.0",
+	"ODC-By":   "ODC-By-1.0",
+	"ODbL":     "ODbL-1.0",
+}
+
+// licenseURL returns the SPDX URL of the license, or the free-text definition for "other"
+func licenseURL(md *DatasetMetadata) string {
+	switch md.License {
+	case "":
+		return ""
+	case "other":
+		return md.DefineLicense
+	}
+
+	id := md.License
+	if alias, ok := licenseAliases[id]; ok {
+		id = alias
+	}
+	return "https://spdx.org/licenses/" + id + ".html"
+}
+
+// jsonLD is a JSON-LD node whose empty values are left out
+type jsonLD map[string]interface{}
+
+func (n jsonLD) set(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	case []jsonLD:
+		if len(v) == 0 {
+			return
+		}
+	case int:
+		if v == 0 {
+			return
+		}
+	case int64:
+		if v == 0 {
+			return
+		}
+	case jsonLD:
+		if len(v) <= 1 {
+			return
+		}
+	}
+	n[key] = value
+}
+
+// contact describes the maintainer, as an email address when it looks like one
+func contact(maintainer string, emailKey string, nameKey string, node jsonLD) jsonLD {
+	if strings.Contains(maintainer, "@") && !strings.Contains(maintainer, " ") {
+		node.set(emailKey, "mailto:"+strings.TrimPrefix(maintainer, "mailto:"))
+	} else {
+		node.set(nameKey, maintainer)
+	}
+	return node
+}
+
+// upstreamIDs returns the IDs the dataset relates to with the relation
+func upstreamIDs(edges []*LineageEdge, relation string) []string {
+	ids := []string{}
+	for _, edge := range edges {
+		if edge.Relation == relation {
+			ids = append(ids, edge.To)
+		}
+	}
+	return ids
+}
+
+// toDCAT maps the metadata to a W3C DCAT dcat:Dataset
+func toDCAT(md *DatasetMetadata, edges []*LineageEdge) jsonLD {
+	node := jsonLD{
+		"@context": jsonLD{
+			"dcat":  "http://www.w3.org/ns/dcat#",
+			"dct":   "http://purl.org/dc/terms/",
+			"foaf":  "http://xmlns.com/foaf/0.1/",
+			"vcard": "http://www.w3.org/2006/vcard/ns#",
+			"prov":  "http://www.w3.org/ns/prov#",
+			"spdx":  "http://spdx.org/rdf/terms#",
+		},
+		"@type":          "dcat:Dataset",
+		"dct:identifier": md.ID,
+	}
+	node.set("dct:title", md.Title)
+	node.set("dct:description", md.Description)
+	if md.Organisation != "" {
+		node.set("dct:publisher", jsonLD{"@type": "foaf:Agent", "foaf:name": md.Organisation})
+	}
+	node.set("dcat:contactPoint", contact(md.Maintainer, "vcard:hasEmail", "vcard:fn", jsonLD{"@type": "vcard:Kind"}))
+	node.set("dct:issued", md.Date)
+	node.set("dct:spatial", md.Location)
+	node.set("dct:license", licenseURL(md))
+	node.set("dcat:keyword", md.Tags)
+	node.set("dct:accrualPeriodicity", md.UpdateFrequency)
+	node.set("dct:source", md.Source)
+	node.set("dct:provenance", md.Methodology)
+	node.set("prov:wasDerivedFrom", upstreamIDs(edges, relationDerivedFrom))
+	node.set("dct:replaces", upstreamIDs(edges, relationSupersedes))
+	node.set("dct:isPartOf", upstreamIDs(edges, relationPartOf))
+
+	distributions := []jsonLD{}
+	if md.Endpoint != "" || md.Content != nil {
+		formats := md.FileTypes
+		if len(formats) == 0 {
+			formats = []string{""}
+		}
+		for _, format := range formats {
+			dist := jsonLD{"@type": "dcat:Distribution"}
+			dist.set("dct:title", md.Name)
+			dist.set("dcat:accessURL", md.Endpoint)
+			dist.set("dct:format", format)
+			if md.Content != nil {
+				dist.set("dcat:byteSize", md.Content.Size)
+				dist.set("spdx:checksum", jsonLD{
+					"spdx:algorithm":     "spdx:checksumAlgorithm_sha256",
+					"spdx:checksumValue": md.Content.SHA256,
+				})
+			}
+			distributions = append(distributions, dist)
+		}
+	}
+	node.set("dcat:distribution", distributions)
+
+	return node
+}
+
+// toSchemaOrg maps the metadata to a schema.org Dataset
+func toSchemaOrg(md *DatasetMetadata, edges []*LineageEdge) jsonLD {
+	node := jsonLD{
+		"@context":   "https://schema.org/",
+		"@type":      "Dataset",
+		"identifier": md.ID,
+	}
+	node.set("name", md.Title)
+	node.set("description", md.Description)
+	if md.Organisation != "" {
+		node.set("creator", jsonLD{"@type": "Organization", "name": md.Organisation})
+	}
+	node.set("maintainer", contact(md.Maintainer, "email", "name", jsonLD{"@type": "Person"}))
+	node.set("datePublished", md.Date)
+	node.set("spatialCoverage", md.Location)
+	node.set("license", licenseURL(md))
+	node.set("keywords", md.Tags)
+	if len(md.Columns) > 0 {
+		variables := []jsonLD{}
+		for _, c := range md.Columns {
+			variable := jsonLD{"@type": "PropertyValue", "name": c.Name}
+			variable.set("description", c.Description)
+			variable.set("unitText", c.Unit)
+			variables = append(variables, variable)
+		}
+		node.set("variableMeasured", variables)
+	} else {
+		node.set("variableMeasured", md.FieldNames)
+	}
+	node.set("measurementTechnique", md.Methodology)
+	node.set("isBasedOn", append(upstreamIDs(edges, relationDerivedFrom), upstreamIDs(edges, relationSupersedes)...))
+	node.set("isPartOf", upstreamIDs(edges, relationPartOf))
+	if md.Source != "" {
+		node.set("sourceOrganization", jsonLD{"@type": "Organization", "name": md.Source})
+	}
+
+	distributions := []jsonLD{}
+	if md.Endpoint != "" || md.Content != nil {
+		formats := md.FileTypes
+		if len(formats) == 0 {
+			formats = []string{""}
+		}
+		for _, format := range formats {
+			dist := jsonLD{"@type": "DataDownload"}
+			dist.set("name", md.Name)
+			dist.set("contentUrl", md.Endpoint)
+			dist.set("encodingFormat", format)
+			if md.Content != nil {
+				dist.set("contentSize", fmt.Sprintf("%d B", md.Content.Size))
+				dist.set("sha256", md.Content.SHA256)
+			}
+			distributions = append(distributions, dist)
+		}
+	}
+	node.set("distribution", distributions)
+
+	return node
+}
+
+// ExportDataset returns the dataset as held by the collection in JSON-LD, either as a DCAT
+// dataset with format "dcat" or as a schema.org Dataset with format "schemaorg"
+func (l *DatasetMetadataLedger) ExportDataset(ctx contractapi.TransactionContextInterface, collection string, key string, format string) (string, error) {
+	if err := requireCertification(ctx, opQuery); err != nil {
+		return "", err
+	}
+
+	if err := requireNotRetired(ctx, collection, key); err != nil {
+		return "", err
+	}
+	if err := requireNotExpired(ctx, collection, key); err != nil {
+		return "", err
+	}
+	mdAsBytes, err := readFromTarget(ctx, collection, key)
+	if err != nil {
+		return "", err
+	}
+	if mdAsBytes == nil {
+		return "", notFoundError(`Dataset "%s" not found in collection "%s".`, key, collection)
+	}
+	md := new(DatasetMetadataPublic)
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return "", err
+	}
+
+	// Lineage is public, so it is exported along with any projection
+	edges, err := readLineageEdges(ctx, upstreamObjectType, key)
+	if err != nil {
+		return "", err
+	}
+
+	var node jsonLD
+	switch format {
+	case formatDCAT:
+		node = toDCAT(md, edges)
+	case formatSchemaOrg:
+		node = toSchemaOrg(md, edges)
+	default:
+		return "", fmt.Errorf(`Unknown export format "%s", expected "%s" or "%s".`, format, formatDCAT, formatSchemaOrg)
+	}
+
+	bs, err := json.Marshal(node)
+	if err != nil {
+		return "", fmt.Errorf("Failed to encode export of \"%s\".\n%v", key, err)
+	}
+
+	return string(bs), nil
+}
@@ -0,0 +1,158 @@
+package contract
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLicenseURL(t *testing.T) {
+	tests := []struct {
+		license       string
+		defineLicense string
+		want          string
+	}{
+		{license: "", want: ""},
+		{license: "ODC-PDDL", want: "https://spdx.org/licenses/PDDL-1.0.html"},
+		{license: "ODbL", want: "https://spdx.org/licenses/ODbL-1.0.html"},
+		{license: "CC-BY-4.0", want: "https://spdx.org/licenses/CC-BY-4.0.html"},
+		{license: "other", defineLicense: "https://example.com/license", want: "https://example.com/license"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.license, func(t *testing.T) {
+			if got := licenseURL(&DatasetMetadata{License: tt.license, DefineLicense: tt.defineLicense}); got != tt.want {
+				t.Errorf("licenseURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportMapping(t *testing.T) {
+	md := &DatasetMetadata{
+		ID:           exampleID,
+		Name:         "data.csv",
+		Title:        "Example",
+		Organisation: "org1.example.com",
+		Maintainer:   "Jane Doe",
+		Date:         "2022-01-01T09:00:00Z",
+		FileTypes:    []string{"csv", "parquet"},
+		License:      "ODC-By",
+		Tags:         []string{"t1"},
+		Columns:      []ColumnSchema{{Name: "x1", Type: "number", Unit: "kg"}},
+		Endpoint:     "https://api.org1.example.com",
+		Content:      &DatasetContent{SHA256: "ab", Size: 10},
+	}
+	edges := []*LineageEdge{
+		{From: exampleID, To: "org1.example.com/raw", Relation: relationDerivedFrom},
+		{From: exampleID, To: "org1.example.com/v1", Relation: relationSupersedes},
+	}
+	encode := func(v interface{}) interface{} {
+		bs, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(bs, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		return decoded
+	}
+
+	tests := []struct {
+		name string
+		node jsonLD
+		key  string
+		want interface{}
+	}{
+		{name: "dcat type", node: toDCAT(md, edges), key: "@type", want: "dcat:Dataset"},
+		{name: "dcat identifier", node: toDCAT(md, edges), key: "dct:identifier", want: exampleID},
+		{name: "dcat publisher", node: toDCAT(md, edges), key: "dct:publisher", want: map[string]interface{}{"@type": "foaf:Agent", "foaf:name": "org1.example.com"}},
+		{name: "dcat contact by name", node: toDCAT(md, edges), key: "dcat:contactPoint", want: map[string]interface{}{"@type": "vcard:Kind", "vcard:fn": "Jane Doe"}},
+		{name: "dcat license", node: toDCAT(md, edges), key: "dct:license", want: "https://spdx.org/licenses/ODC-By-1.0.html"},
+		{name: "dcat derived from", node: toDCAT(md, edges), key: "prov:wasDerivedFrom", want: []interface{}{"org1.example.com/raw"}},
+		{name: "dcat replaces", node: toDCAT(md, edges), key: "dct:replaces", want: []interface{}{"org1.example.com/v1"}},
+		{name: "dcat part of", node: toDCAT(md, edges), key: "dct:isPartOf"},
+		{name: "dcat no description", node: toDCAT(md, edges), key: "dct:description"},
+		{name: "schema.org type", node: toSchemaOrg(md, edges), key: "@type", want: "Dataset"},
+		{name: "schema.org based on", node: toSchemaOrg(md, edges), key: "isBasedOn", want: []interface{}{"org1.example.com/raw", "org1.example.com/v1"}},
+		{name: "schema.org variables", node: toSchemaOrg(md, edges), key: "variableMeasured", want: []interface{}{map[string]interface{}{"@type": "PropertyValue", "name": "x1", "unitText": "kg"}}},
+		{name: "schema.org maintainer", node: toSchemaOrg(md, edges), key: "maintainer", want: map[string]interface{}{"@type": "Person", "name": "Jane Doe"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := encode(tt.node).(map[string]interface{})[tt.key]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("%s = %v, want none", tt.key, got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("distribution per file type", func(t *testing.T) {
+		dists := toDCAT(md, nil)["dcat:distribution"].([]jsonLD)
+		if len(dists) != 2 || dists[0]["dct:format"] != "csv" || dists[1]["dct:format"] != "parquet" {
+			t.Fatalf("distributions = %v", dists)
+		}
+		if dists[0]["dcat:accessURL"] != md.Endpoint || dists[0]["dcat:byteSize"] != int64(10) {
+			t.Errorf("distribution = %v", dists[0])
+		}
+	})
+
+	t.Run("email maintainer", func(t *testing.T) {
+		node := toSchemaOrg(&DatasetMetadata{ID: exampleID, Maintainer: "root@org1.example.com"}, nil)
+		if got := node["maintainer"].(jsonLD)["email"]; got != "mailto:root@org1.example.com" {
+			t.Errorf("email = %v", got)
+		}
+		if _, ok := node["distribution"]; ok {
+			t.Errorf("distribution without endpoint or content: %v", node["distribution"])
+		}
+	})
+}
+
+func TestExportDataset(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	tests := []struct {
+		name   string
+		format string
+		key    string
+		want   string
+		err    string
+	}{
+		{name: "dcat", format: "dcat", key: "dct:identifier", want: exampleID},
+		{name: "schema.org", format: "schemaorg", key: "identifier", want: exampleID},
+		{name: "endpoint withheld", format: "dcat", key: "dcat:distribution"},
+		{name: "unknown format", format: "ckan", err: `Unknown export format "ckan"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := l.ExportDataset(as(t, stub, org2Reader, nil), "", exampleID, tt.format)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var node map[string]interface{}
+			if err := json.Unmarshal([]byte(out), &node); err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := node[tt.key]; (tt.want == "" && ok) || (tt.want != "" && got != tt.want) {
+				t.Errorf("%s = %v, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := l.ExportDataset(as(t, stub, org2Reader, nil), "", "org1.example.com/none", formatDCAT)
+		requireError(t, err, `Dataset "org1.example.com/none" not found in collection ""`)
+	})
+}
@@ -0,0 +1,216 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// catalog indexes are kept next to every public copy of a dataset and are built from
+// the bytes of that copy, so they only cover the facets disclosed to its collection
+const (
+	tagIndex      = "tag~id"
+	orgIndex      = "org~id"
+	licenseIndex  = "license~id"
+	fileTypeIndex = "filetype~id"
+	fieldIndex    = "field~id"
+)
+
+// composite key entries only need a non-nil value
+var indexValue = []byte{0x00}
+
+func normaliseFacet(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// catalogIndexKeys returns the distinct index keys of the copy of a dataset written to a target
+func catalogIndexKeys(ctx contractapi.TransactionContextInterface, copyAsBytes []byte) ([]string, error) {
+	md := new(DatasetMetadata)
+	if err := md.FromBytes(copyAsBytes); err != nil {
+		return nil, err
+	}
+
+	facets := [][2]string{
+		{orgIndex, md.Organisation},
+		{licenseIndex, md.License},
+	}
+	for _, tag := range md.Tags {
+		facets = append(facets, [2]string{tagIndex, tag})
+	}
+	for _, fileType := range md.FileTypes {
+		facets = append(facets, [2]string{fileTypeIndex, fileType})
+	}
+	for _, field := range md.FieldNames {
+		facets = append(facets, [2]string{fieldIndex, field})
+	}
+	for _, c := range md.Columns {
+		facets = append(facets, [2]string{fieldIndex, c.Name})
+	}
+
+	keys := []string{}
+	for _, facet := range facets {
+		value := normaliseFacet(facet[1])
+		if value == "" {
+			continue
+		}
+		key, err := createCompositeKey(ctx, facet[0], value, md.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func createCatalogIndexes(ctx contractapi.TransactionContextInterface, target string, copyAsBytes []byte) error {
+	keys, err := catalogIndexKeys(ctx, copyAsBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := createFromTargets(ctx, []string{target}, key, indexValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteCatalogIndexes(ctx contractapi.TransactionContextInterface, target string, copyAsBytes []byte) error {
+	keys, err := catalogIndexKeys(ctx, copyAsBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := deleteFromTargets(ctx, []string{target}, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateCatalogIndexes only touches the index entries that differ between revisions
+func updateCatalogIndexes(ctx contractapi.TransactionContextInterface, target string, prevAsBytes []byte, copyAsBytes []byte) error {
+	prevKeys, err := catalogIndexKeys(ctx, prevAsBytes)
+	if err != nil {
+		return err
+	}
+	keys, err := catalogIndexKeys(ctx, copyAsBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range prevKeys {
+		if !containsString(keys, key) {
+			if err := deleteFromTargets(ctx, []string{target}, key); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range keys {
+		if !containsString(prevKeys, key) {
+			if err := createFromTargets(ctx, []string{target}, key, indexValue); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFromTargetByIndex returns the IDs indexed under the value, where "" stands for the public ledger
+func readFromTargetByIndex(ctx contractapi.TransactionContextInterface, target string, index string, value string, max int) ([]string, error) {
+	var it shim.StateQueryIteratorInterface
+	var err error
+	if target != "" {
+		it, err = ctx.GetStub().GetPrivateDataByPartialCompositeKey(target, index, []string{value})
+	} else {
+		it, err = ctx.GetStub().GetStateByPartialCompositeKey(index, []string{value})
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read index "%s" with value "%s" : %v`, index, value, err)
+	}
+	defer it.Close()
+
+	ids := []string{}
+	for it.HasNext() && len(ids) < max {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(item.Key)
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to split composite key "%s" : %v`, item.Key, err)
+		}
+		ids = append(ids, attributes[1])
+	}
+
+	return ids, nil
+}
+
+func queryByIndex(ctx contractapi.TransactionContextInterface, target string, index string, value string, max int) ([]*DatasetMetadataPublic, error) {
+	if err := requireCertification(ctx, opQueryByIndex); err != nil {
+		return nil, err
+	}
+
+	ids, err := readFromTargetByIndex(ctx, target, index, normaliseFacet(value), max)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*DatasetMetadataPublic{}
+	for _, id := range ids {
+		mdAsBytes, err := readFromTarget(ctx, target, id)
+		if err != nil {
+			return nil, err
+		}
+		// skip entries whose dataset is gone or expired
+		if mdAsBytes == nil {
+			continue
+		}
+		expired, _, err := isExpired(ctx, target, id)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			continue
+		}
+		md := new(DatasetMetadataPublic)
+		if err := md.FromBytes(mdAsBytes); err != nil {
+			return nil, err
+		}
+		result = append(result, md)
+	}
+
+	return result, nil
+}
+
+func (l *DatasetMetadataLedger) QueryByTag(ctx contractapi.TransactionContextInterface, collection string, tag string, max int) ([]*DatasetMetadataPublic, error) {
+	return queryByIndex(ctx, collection, tagIndex, tag, max)
+}
+
+func (l *DatasetMetadataLedger) QueryByOrganisation(ctx contractapi.TransactionContextInterface, collection string, organisation string, max int) ([]*DatasetMetadataPublic, error) {
+	return queryByIndex(ctx, collection, orgIndex, organisation, max)
+}
+
+func (l *DatasetMetadataLedger) QueryByLicense(ctx contractapi.TransactionContextInterface, collection string, license string, max int) ([]*DatasetMetadataPublic, error) {
+	return queryByIndex(ctx, collection, licenseIndex, license, max)
+}
+
+func (l *DatasetMetadataLedger) QueryByFileType(ctx contractapi.TransactionContextInterface, collection string, fileType string, max int) ([]*DatasetMetadataPublic, error) {
+	return queryByIndex(ctx, collection, fileTypeIndex, fileType, max)
+}
+
+// QueryByField finds the datasets with a column of the given name, among those whose schema or
+// field names are disclosed to the collection
+func (l *DatasetMetadataLedger) QueryByField(ctx contractapi.TransactionContextInterface, collection string, field string, max int) ([]*DatasetMetadataPublic, error) {
+	return queryByIndex(ctx, collection, fieldIndex, field, max)
+}
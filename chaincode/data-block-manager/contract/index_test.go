@@ -0,0 +1,86 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+// indexedValues returns the values indexed under the index in the target, "" standing for the public ledger
+func indexedValues(stub *testStub, target string, index string) []string {
+	values := []string{}
+	for key := range stub.state[target] {
+		if strings.HasPrefix(key, "\x00"+index+"\x00") {
+			values = append(values, strings.Split(key, "\x00")[2])
+		}
+	}
+	return values
+}
+
+func TestCatalogIndexesFollowWrittenCopy(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const collection = "publicDataBlockCollection"
+
+	// The public ledger only gets the title and license, the collection gets the facets as well
+	policy := `{"default": ["title", "license", "tags", "fileTypes", "fieldNames"], "collections": {"": ["title", "license"]}}`
+	if err := l.SetDisclosurePolicy(as(t, stub, org1Admin, nil), policy); err != nil {
+		t.Fatalf("SetDisclosurePolicy: %v", err)
+	}
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "", collection)
+
+	tests := []struct {
+		name   string
+		target string
+		index  string
+		want   []string
+	}{
+		{name: "public tags", target: "", index: tagIndex},
+		{name: "public file types", target: "", index: fileTypeIndex},
+		{name: "public fields", target: "", index: fieldIndex},
+		{name: "public license", target: "", index: licenseIndex, want: []string{"odc-pddl"}},
+		{name: "public organisation", target: "", index: orgIndex},
+		{name: "collection tags", target: collection, index: tagIndex, want: []string{"t1", "t2"}},
+		{name: "collection file types", target: collection, index: fileTypeIndex, want: []string{"csv"}},
+		{name: "collection organisation", target: collection, index: orgIndex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := indexedValues(stub, tt.target, tt.index)
+			if len(got) != len(tt.want) {
+				t.Fatalf("indexed values = %v, want %v", got, tt.want)
+			}
+			for _, value := range tt.want {
+				if !containsString(got, value) {
+					t.Errorf("indexed values = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("update", func(t *testing.T) {
+		updated := strings.Replace(exampleMetadata, `"tags": ["t1", "t2"]`, `"tags": ["t2", "t3"]`, 1)
+		if err := l.Update(as(t, stub, org1Registrar, map[string][]byte{"metadata": []byte(updated)})); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got := indexedValues(stub, "", tagIndex); len(got) != 0 {
+			t.Errorf("public tags = %v, want none", got)
+		}
+		got := indexedValues(stub, collection, tagIndex)
+		if len(got) != 2 || !containsString(got, "t2") || !containsString(got, "t3") {
+			t.Errorf("collection tags = %v, want [t2 t3]", got)
+		}
+	})
+
+	t.Run("deregister", func(t *testing.T) {
+		if err := l.Deregister(as(t, stub, org1Registrar, nil), exampleID, "test"); err != nil {
+			t.Fatalf("Deregister: %v", err)
+		}
+		for _, target := range []string{"", collection} {
+			for _, index := range []string{tagIndex, licenseIndex, fileTypeIndex} {
+				if got := indexedValues(stub, target, index); len(got) != 0 {
+					t.Errorf("%s in %q = %v after deregistration", index, target, got)
+				}
+			}
+		}
+	})
+}
@@ -17,7 +17,7 @@ func (l *DatasetMetadataLedger) Register(ctx contractapi.TransactionContextInter
 		return err
 	}
 
-	if err := requireCertification(ctx, nil); err != nil {
+	if err := requireCertification(ctx, opRegister); err != nil {
 		return err
 	}
 
@@ -43,31 +43,140 @@ func (l *DatasetMetadataLedger) Register(ctx contractapi.TransactionContextInter
 		return fmt.Errorf("Failed to decode collections : %v", err)
 	}
 
-	mdPublic := new(DatasetMetadataPublic)
-	if err := mdPublic.FromBytes(mdInputAsBytes); err != nil {
+	md, err := prepareRegistration(ctx, mspID, mdInputAsBytes, collections)
+	if err != nil {
+		return err
+	}
+	retentionClass, expiresAt, err := readRetentionInput(ctx, transient)
+	if err != nil {
 		return err
 	}
-	if err := mdPublic.Validate(); err != nil {
+
+	// Record the registration in implicit collection
+	clientID, err := getClientID(ctx)
+	if err != nil {
 		return err
 	}
-	mdPublicAsBytes, err := mdPublic.ToBytes()
+	ts, err := getTxTimestamp(ctx)
 	if err != nil {
 		return err
 	}
+	policy, err := readDisclosurePolicy(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if err := writeNewDataset(ctx, policy, &DatasetRegistration{
+		ID:          md.ID,
+		Owner:       mspID,
+		Registrar:   clientID,
+		Revision:    1,
+		Collections: collections,
+		CreatedAt:   ts,
+		UpdatedAt:   ts,
+
+		RetentionClass: retentionClass,
+		ExpiresAt:      expiresAt,
+	}, md); err != nil {
+		return err
+	}
+
+	return emitDatasetEvent(ctx, eventDatasetRegistered, &DatasetEvent{
+		ID:          md.ID,
+		Owner:       mspID,
+		Collections: collections,
+		Revision:    1,
+	})
+}
+
+// prepareRegistration decodes and validates metadata to be registered, without writing anything
+func prepareRegistration(ctx contractapi.TransactionContextInterface, mspID string, mdInputAsBytes []byte, collections []string) (*DatasetMetadata, error) {
+	md := new(DatasetMetadata)
+	if err := md.FromBytes(mdInputAsBytes); err != nil {
+		return nil, err
+	}
+	if err := md.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateAgainstSchema(ctx, mspID, mdInputAsBytes); err != nil {
+		return nil, err
+	}
+
+	// Only collections the Org is a member of can be written to, each at most once.
+	// The implicit collection always holds the full metadata, so it cannot also hold a projection.
+	implicitCollection := implicitPrivateDataCollection(mspID)
+	for i, target := range collections {
+		if target == implicitCollection {
+			return nil, fmt.Errorf(`Collection "%s" is the implicit collection of Org "%s" and cannot be listed.`, target, mspID)
+		}
+		if containsString(collections[:i], target) {
+			return nil, fmt.Errorf(`Collection "%s" is listed more than once.`, target)
+		}
+		if err := requireCollectionMember(ctx, mspID, target); err != nil {
+			return nil, err
+		}
+	}
 
-	for _, collection := range collections {
-		if collection != "" {
-			if err := createFromCollection(ctx, collection, mdPublic.ID, mdPublicAsBytes); err != nil {
-				return err
-			}
-		} else {
-			if err := createFromPublic(ctx, mdPublic.ID, mdPublicAsBytes); err != nil {
-				return err
-			}
+	// Retired IDs are not reusable
+	for _, target := range append(append([]string{}, collections...), implicitCollection) {
+		if err := requireNotRetired(ctx, target, md.ID); err != nil {
+			return nil, err
 		}
 	}
+	prevAsBytes, err := readFromCollection(ctx, implicitCollection, md.ID)
+	if err != nil {
+		return nil, err
+	}
+	if prevAsBytes != nil {
+		return nil, fmt.Errorf(`Dataset "%s" is already registered by Org "%s".`, md.ID, mspID)
+	}
+
+	return md, nil
+}
+
+// writeNewDataset publishes the projections of a validated dataset and records it in the implicit collection of the owner
+func writeNewDataset(ctx contractapi.TransactionContextInterface, policy *DisclosurePolicy, reg *DatasetRegistration, md *DatasetMetadata) error {
+	// Write the projection of each collection, following the disclosure policy of the Org
+	if err := publishProjections(ctx, policy, reg.Collections, md); err != nil {
+		return err
+	}
 
 	// Write to implicit collection
+	mdAsBytes, err := md.ToBytes()
+	if err != nil {
+		return err
+	}
+	if err := createFromCollection(ctx, implicitPrivateDataCollection(reg.Owner), md.ID, mdAsBytes); err != nil {
+		return err
+	}
+
+	if err := writeRetention(ctx, reg); err != nil {
+		return err
+	}
+
+	return writeRegistration(ctx, reg)
+}
+
+func (l *DatasetMetadataLedger) Update(ctx contractapi.TransactionContextInterface) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opUpdate); err != nil {
+		return err
+	}
+
+	transient, err := getTxTransient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Read metadata input from transient
+	mdInputAsBytes, ok := transient["metadata"]
+	if !ok {
+		return fmt.Errorf("Dataset metadata not defined in transient.")
+	}
+
 	md := new(DatasetMetadata)
 	if err := md.FromBytes(mdInputAsBytes); err != nil {
 		return err
@@ -75,43 +184,196 @@ func (l *DatasetMetadataLedger) Register(ctx contractapi.TransactionContextInter
 	if err := md.Validate(); err != nil {
 		return err
 	}
+	if err := validateAgainstSchema(ctx, mspID, mdInputAsBytes); err != nil {
+		return err
+	}
+
+	if err := requireNotRetired(ctx, implicitPrivateDataCollection(mspID), md.ID); err != nil {
+		return err
+	}
+
+	reg, err := readRegistration(ctx, mspID, md.ID)
+	if err != nil {
+		return err
+	}
+	if reg == nil {
+		return fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, md.ID, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return err
+	}
+
+	// Keep the current revision in implicit collection
+	implicitCollection := implicitPrivateDataCollection(mspID)
+	prevAsBytes, err := readFromCollection(ctx, implicitCollection, md.ID)
+	if err != nil {
+		return err
+	}
+	if prevAsBytes == nil {
+		return fmt.Errorf(`Dataset "%s" not found in collection "%s".`, md.ID, implicitCollection)
+	}
+	revKey, err := revisionKey(ctx, md.ID, reg.Revision)
+	if err != nil {
+		return err
+	}
+	if err := createFromCollection(ctx, implicitCollection, revKey, prevAsBytes); err != nil {
+		return err
+	}
+
+	// Overwrite implicit collection
 	mdAsBytes, err := md.ToBytes()
 	if err != nil {
 		return err
 	}
+	if err := updateFromCollection(ctx, implicitCollection, md.ID, mdAsBytes); err != nil {
+		return err
+	}
 
-	if err := createFromCollection(ctx, implicitPrivateDataCollection(mspID), md.ID, mdAsBytes); err != nil {
+	// Overwrite public copies where the dataset was registered
+	policy, err := readDisclosurePolicy(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if err := republishProjections(ctx, policy, reg.Collections, md); err != nil {
 		return err
 	}
 
-	return nil
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+	reg.Revision++
+	reg.UpdatedAt = ts
+	if err := writeRegistration(ctx, reg); err != nil {
+		return err
+	}
+
+	return emitDatasetEvent(ctx, eventDatasetUpdated, &DatasetEvent{
+		ID:          md.ID,
+		Owner:       mspID,
+		Collections: reg.Collections,
+		Revision:    reg.Revision,
+	})
 }
 
-func (l *DatasetMetadataLedger) Query(ctx contractapi.TransactionContextInterface, collection string, key string) (*DatasetMetadataPublic, error) {
-	if err := requireCertification(ctx, nil); err != nil {
-		return nil, err
+// Deregister removes a dataset from every collection it was published to and leaves a tombstone in its place
+func (l *DatasetMetadataLedger) Deregister(ctx contractapi.TransactionContextInterface, key string, reason string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
 	}
 
-	md := new(DatasetMetadataPublic)
+	if err := requireCertification(ctx, opDeregister); err != nil {
+		return err
+	}
 
-	if collection != "" {
-		mdAsBytes, err := readFromCollection(ctx, collection, key)
-		if err != nil {
-			return nil, err
-		}
-		if err := md.FromBytes(mdAsBytes); err != nil {
-			return nil, err
-		}
-	} else {
-		mdAsBytes, err := readFromPublic(ctx, key)
+	implicitCollection := implicitPrivateDataCollection(mspID)
+	if err := requireNotRetired(ctx, implicitCollection, key); err != nil {
+		return err
+	}
+
+	reg, err := readRegistration(ctx, mspID, key)
+	if err != nil {
+		return err
+	}
+	if reg == nil {
+		return fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, key, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return err
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	tombstone := &DatasetTombstone{
+		ID:        key,
+		Owner:     mspID,
+		RetiredBy: clientID,
+		RetiredAt: ts,
+		Reason:    reason,
+		Revision:  reg.Revision,
+	}
+	tombstoneAsBytes, err := tombstone.ToBytes()
+	if err != nil {
+		return err
+	}
+	tsKey, err := tombstoneKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := withdrawProjections(ctx, reg.Collections, key); err != nil {
+		return err
+	}
+	if err := deleteRetention(ctx, reg); err != nil {
+		return err
+	}
+	if err := deleteFromCollection(ctx, implicitCollection, key); err != nil {
+		return err
+	}
+
+	targets := append(append([]string{}, reg.Collections...), implicitCollection)
+	if err := createFromTargets(ctx, targets, tsKey, tombstoneAsBytes); err != nil {
+		return err
+	}
+
+	// Drop the registration and revision history of the dataset
+	regKey, err := registrationKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := deleteFromCollection(ctx, implicitCollection, regKey); err != nil {
+		return err
+	}
+	for revision := 1; revision < reg.Revision; revision++ {
+		revKey, err := revisionKey(ctx, key, revision)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if err := md.FromBytes(mdAsBytes); err != nil {
-			return nil, err
+		if err := deleteFromCollection(ctx, implicitCollection, revKey); err != nil {
+			return err
 		}
 	}
 
+	return emitDatasetEvent(ctx, eventDatasetRetired, &DatasetEvent{
+		ID:          key,
+		Owner:       mspID,
+		Collections: reg.Collections,
+		Revision:    reg.Revision,
+	})
+}
+
+func (l *DatasetMetadataLedger) Query(ctx contractapi.TransactionContextInterface, collection string, key string) (*DatasetMetadataPublic, error) {
+	if err := requireCertification(ctx, opQuery); err != nil {
+		return nil, err
+	}
+
+	if err := requireNotRetired(ctx, collection, key); err != nil {
+		return nil, err
+	}
+	if err := requireNotExpired(ctx, collection, key); err != nil {
+		return nil, err
+	}
+
+	md := new(DatasetMetadataPublic)
+	mdAsBytes, err := readFromTarget(ctx, collection, key)
+	if err != nil {
+		return nil, err
+	}
+	if mdAsBytes == nil {
+		return nil, notFoundError(`Dataset "%s" does not exist in collection "%s".`, key, collection)
+	}
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return nil, err
+	}
+
 	return md, nil
 }
 
@@ -121,7 +383,11 @@ func (l *DatasetMetadataLedger) QueryPrivate(ctx contractapi.TransactionContextI
 		return nil, err
 	}
 
-	if err := requireCertification(ctx, nil); err != nil {
+	if err := requireCertification(ctx, opQueryPrivate); err != nil {
+		return nil, err
+	}
+
+	if err := requireNotRetired(ctx, implicitPrivateDataCollection(mspID), key); err != nil {
 		return nil, err
 	}
 
@@ -130,6 +396,9 @@ func (l *DatasetMetadataLedger) QueryPrivate(ctx contractapi.TransactionContextI
 	if err != nil {
 		return nil, err
 	}
+	if mdAsBytes == nil {
+		return nil, notFoundError(`Dataset "%s" does not exist in collection "%s".`, key, implicitPrivateDataCollection(mspID))
+	}
 	if err := md.FromBytes(mdAsBytes); err != nil {
 		return nil, err
 	}
@@ -137,32 +406,120 @@ func (l *DatasetMetadataLedger) QueryPrivate(ctx contractapi.TransactionContextI
 	return md, nil
 }
 
-func (l *DatasetMetadataLedger) QueryByRange(ctx contractapi.TransactionContextInterface, collection string, start string, end string, max int) ([]*DatasetMetadataPublic, error) {
-	if err := requireCertification(ctx, nil); err != nil {
+func (l *DatasetMetadataLedger) QueryRevision(ctx contractapi.TransactionContextInterface, key string, revision int) (*DatasetMetadata, error) {
+	mspID, err := getPeerMSPID()
+	if err != nil {
 		return nil, err
 	}
 
-	toMdArray := func(bsArray [][]byte) ([]*DatasetMetadataPublic, error) {
-		result := make([]*DatasetMetadataPublic, len(bsArray))
-		for i, md := range result {
-			if err := md.FromBytes(bsArray[i]); err != nil {
-				return nil, err
-			}
-		}
-		return result, nil
+	if err := requireCertification(ctx, opQueryRevision); err != nil {
+		return nil, err
 	}
 
-	if collection != "" {
-		bsArray, err := readFromCollectionByRange(ctx, collection, start, end, max)
+	if err := requireNotRetired(ctx, implicitPrivateDataCollection(mspID), key); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, key)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, key, mspID)
+	}
+	if revision < 1 || revision > reg.Revision {
+		return nil, fmt.Errorf(`Dataset "%s" has no revision %d, latest is %d.`, key, revision, reg.Revision)
+	}
+
+	// The latest revision is the current metadata
+	revKey := key
+	if revision < reg.Revision {
+		revKey, err = revisionKey(ctx, key, revision)
 		if err != nil {
 			return nil, err
 		}
-		return toMdArray(bsArray)
+	}
+
+	md := new(DatasetMetadata)
+	mdAsBytes, err := readFromCollection(ctx, implicitPrivateDataCollection(mspID), revKey)
+	if err != nil {
+		return nil, err
+	}
+	if mdAsBytes == nil {
+		return nil, notFoundError(`Revision %d of dataset "%s" does not exist in collection "%s".`, revision, key, implicitPrivateDataCollection(mspID))
+	}
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+// KeyValueRecord is a dataset read by range together with its key
+type KeyValueRecord struct {
+	Key    string                 `json:"key"`
+	Record *DatasetMetadataPublic `json:"record"`
+}
+
+// RangeQueryResult is one page of a range query, pass Bookmark to fetch the next page
+type RangeQueryResult struct {
+	Records             []*KeyValueRecord `json:"records"`
+	FetchedRecordsCount int32             `json:"fetchedRecordsCount"`
+	Bookmark            string            `json:"bookmark"`
+}
+
+// maxRangePageSize caps the page size of range queries to keep responses within the peer limits
+const maxRangePageSize = 200
+
+// QueryByRange returns the datasets whose keys fall in [start, end) on the public ledger or in a
+// named collection, one page at a time. An empty end leaves the range open. Expired datasets are
+// left out of the page.
+func (l *DatasetMetadataLedger) QueryByRange(ctx contractapi.TransactionContextInterface, collection string, start string, end string, pageSize int, bookmark string) (*RangeQueryResult, error) {
+	if err := requireCertification(ctx, opQueryByRange); err != nil {
+		return nil, err
+	}
+
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("Page size must be positive, got %d.", pageSize)
+	}
+	if pageSize > maxRangePageSize {
+		pageSize = maxRangePageSize
+	}
+
+	var items []*keyValue
+	var next string
+	var err error
+	if collection != "" {
+		items, next, err = readFromCollectionByRange(ctx, collection, start, end, pageSize, bookmark)
 	} else {
-		bsArray, err := readFromPublicByRange(ctx, start, end, max)
+		items, next, err = readFromPublicByRange(ctx, start, end, pageSize, bookmark)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*KeyValueRecord{}
+	for _, item := range items {
+		if isCompositeKey(item.Key) {
+			continue
+		}
+		expired, _, err := isExpired(ctx, collection, item.Key)
 		if err != nil {
 			return nil, err
 		}
-		return toMdArray(bsArray)
+		if expired {
+			continue
+		}
+		md := new(DatasetMetadataPublic)
+		if err := md.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		records = append(records, &KeyValueRecord{Key: item.Key, Record: md})
 	}
+
+	return &RangeQueryResult{
+		Records:             records,
+		FetchedRecordsCount: int32(len(items)),
+		Bookmark:            next,
+	}, nil
 }
@@ -0,0 +1,237 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exampleMetadata = `{
+	"id": "org1.example.com/data001",
+	"name": "org1.example.com-data.csv",
+	"title": "Org1's example dataset",
+	"organisation": "org1.example.com",
+	"maintainer": "root@org1.example.com",
+	"date": "2022-01-01T09:00:00.000Z",
+	"fieldNames": ["x1", "x2"],
+	"fileTypes": ["csv"],
+	"numberOfRows": 100,
+	"license": "ODC-PDDL",
+	"tags": ["t1", "t2"],
+	"endpoint": "https://api.org1.example.com"
+}`
+
+const exampleID = "org1.example.com/data001"
+
+func TestRegisterCollections(t *testing.T) {
+	tests := []struct {
+		name        string
+		collections []string
+		err         string
+	}{
+		{name: "public and shared", collections: []string{"", "publicDataBlockCollection"}},
+		{name: "duplicate", collections: []string{"", "publicDataBlockCollection", ""}, err: `Collection "" is listed more than once`},
+		{name: "implicit", collections: []string{implicitPrivateDataCollection("Org1MSP")}, err: `is the implicit collection of Org "Org1MSP"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newTestStub()
+			l := new(DatasetMetadataLedger)
+			err := l.Register(as(t, stub, org1Registrar, registerTransient(t, exampleMetadata, tt.collections...)))
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				if stub.get(implicitPrivateDataCollection("Org1MSP"), exampleID) != nil {
+					t.Error("rejected dataset was written to the implicit collection")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryRevision(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	updated := `{"id": "org1.example.com/data001", "title": "Updated title", "date": "2022-02-01T09:00:00.000Z"}`
+	if err := l.Update(as(t, stub, org1Registrar, map[string][]byte{"metadata": []byte(updated)})); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		revision int
+		title    string
+		err      string
+	}{
+		{name: "previous", revision: 1, title: "Org1's example dataset"},
+		{name: "latest", revision: 2, title: "Updated title"},
+		{name: "zero", revision: 0, err: "has no revision 0, latest is 2"},
+		{name: "future", revision: 3, err: "has no revision 3, latest is 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md, err := l.QueryRevision(as(t, stub, org1Registrar, nil), exampleID, tt.revision)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("QueryRevision: %v", err)
+			}
+			if md.Title != tt.title {
+				t.Errorf("title = %q, want %q", md.Title, tt.title)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		ctx := as(t, stub, org1Registrar, nil)
+		revKey, err := revisionKey(ctx, exampleID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		delete(stub.state[implicitPrivateDataCollection("Org1MSP")], revKey)
+
+		_, err = l.QueryRevision(ctx, exampleID, 1)
+		requireError(t, err, `Revision 1 of dataset "org1.example.com/data001" does not exist`)
+	})
+}
+
+func TestQueryByRange(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const collection = "publicDataBlockCollection"
+	ids := []string{}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("org1.example.com/data%03d", i)
+		mustRegister(t, stub, org1Registrar, metadataWithID(id), "", collection)
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name       string
+		collection string
+		start      string
+		end        string
+		pageSize   int
+		pages      [][]string
+		err        string
+	}{
+		{name: "public in pages", pageSize: 2, pages: [][]string{ids[0:2], ids[2:4], ids[4:5]}},
+		{name: "collection in pages", collection: collection, pageSize: 2, pages: [][]string{ids[0:2], ids[2:4], ids[4:5]}},
+		{name: "public exact pages", pageSize: 5, pages: [][]string{ids}},
+		{name: "collection exact pages", collection: collection, pageSize: 5, pages: [][]string{ids}},
+		{name: "collection bounded", collection: collection, start: ids[1], end: ids[4], pageSize: 2, pages: [][]string{ids[1:3], ids[3:4]}},
+		{name: "collection above cap", collection: collection, pageSize: maxRangePageSize + 1, pages: [][]string{ids}},
+		{name: "collection empty", collection: "unusedCollection", pageSize: 2, pages: [][]string{{}}},
+		{name: "zero page size", pageSize: 0, err: "Page size must be positive, got 0"},
+		{name: "negative page size", collection: collection, pageSize: -1, err: "Page size must be positive, got -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookmark := ""
+			for i, want := range tt.pages {
+				result, err := l.QueryByRange(as(t, stub, org1Reader, nil), tt.collection, tt.start, tt.end, tt.pageSize, bookmark)
+				if err != nil {
+					t.Fatalf("page %d: %v", i, err)
+				}
+				got := []string{}
+				for _, record := range result.Records {
+					got = append(got, record.Key)
+				}
+				if strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Fatalf("page %d = %v, want %v", i, got, want)
+				}
+				bookmark = result.Bookmark
+				if last := i == len(tt.pages)-1; last != (bookmark == "") {
+					t.Fatalf("page %d has bookmark %q", i, bookmark)
+				}
+			}
+			if tt.err != "" {
+				_, err := l.QueryByRange(as(t, stub, org1Reader, nil), tt.collection, tt.start, tt.end, tt.pageSize, "")
+				requireError(t, err, tt.err)
+			}
+		})
+	}
+
+	t.Run("expired left out", func(t *testing.T) {
+		transient := registerTransient(t, metadataWithID("org1.example.com/data005"), collection)
+		transient["retention"] = []byte(`{"class":"short"}`)
+		if err := l.Register(as(t, stub, org1Registrar, transient)); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		stub.txTime = stub.txTime.Add(31 * 24 * time.Hour)
+
+		result, err := l.QueryByRange(as(t, stub, org1Reader, nil), collection, ids[4], "", 10, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Records) != 1 || result.Records[0].Key != ids[4] || result.FetchedRecordsCount != 2 {
+			t.Errorf("records = %+v, fetched %d", result.Records, result.FetchedRecordsCount)
+		}
+	})
+}
+
+func TestNotFoundCode(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const (
+		retiredID = "org1.example.com/retired"
+		expiredID = "org1.example.com/expired"
+	)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+	mustRegister(t, stub, org1Registrar, metadataWithID(retiredID), "")
+	if err := l.Deregister(as(t, stub, org1Registrar, nil), retiredID, "test"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+	transient := registerTransient(t, metadataWithID(expiredID), "")
+	transient["retention"] = []byte(`{"class":"short"}`)
+	if err := l.Register(as(t, stub, org1Registrar, transient)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	stub.txTime = stub.txTime.Add(31 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		query    func() error
+		notFound bool
+	}{
+		{name: "missing", query: func() error {
+			_, err := l.Query(as(t, stub, org2Reader, nil), "", "org1.example.com/none")
+			return err
+		}, notFound: true},
+		{name: "retired", query: func() error {
+			_, err := l.Query(as(t, stub, org2Reader, nil), "", retiredID)
+			return err
+		}, notFound: true},
+		{name: "expired", query: func() error {
+			_, err := l.Query(as(t, stub, org2Reader, nil), "", expiredID)
+			return err
+		}, notFound: true},
+		{name: "missing private", query: func() error {
+			_, err := l.QueryPrivate(as(t, stub, org2Registrar, nil), exampleID)
+			return err
+		}, notFound: true},
+		{name: "denied", query: func() error {
+			_, err := l.QueryPrivate(as(t, stub, org1Reader, nil), exampleID)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := strings.HasPrefix(err.Error(), notFoundCode+": "); got != tt.notFound {
+				t.Errorf("error %q has not found code: %v, want %v", err.Error(), got, tt.notFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// lineage edges are kept on the public ledger under both ends, so that the graph can be
+// walked upstream from a dataset and downstream from its sources. Since every edge discloses
+// the IDs of both ends, only datasets with a public copy or tombstone can be related.
+const (
+	upstreamObjectType   = "lineage~up"
+	downstreamObjectType = "lineage~down"
+)
+
+// relations between datasets
+const (
+	relationDerivedFrom = "derivedFrom"
+	relationSupersedes  = "supersedes"
+	relationPartOf      = "partOf"
+)
+
+var lineageRelations = []string{relationDerivedFrom, relationSupersedes, relationPartOf}
+
+// LineageEdge states that dataset From relates to dataset To, e.g. From is derivedFrom To
+type LineageEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+	// How From was obtained from To
+	Transformation string `json:"transformation,omitempty"`
+	// MSP ID of the organisation owning From
+	Owner     string `json:"owner"`
+	CreatedBy string `json:"createdBy"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// LineageGraph is the part of the lineage graph reachable from Root
+type LineageGraph struct {
+	Root  string         `json:"root"`
+	Nodes []string       `json:"nodes"`
+	Edges []*LineageEdge `json:"edges"`
+}
+
+func (e *LineageEdge) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*e)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode lineage edge to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (e *LineageEdge) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, e)
+	if err != nil {
+		return fmt.Errorf("Failed to decode lineage edge.\n%v", err)
+	}
+
+	return nil
+}
+
+func lineageKeys(ctx contractapi.TransactionContextInterface, from string, to string, relation string) (string, string, error) {
+	upKey, err := createCompositeKey(ctx, upstreamObjectType, from, relation, to)
+	if err != nil {
+		return "", "", err
+	}
+	downKey, err := createCompositeKey(ctx, downstreamObjectType, to, relation, from)
+	if err != nil {
+		return "", "", err
+	}
+
+	return upKey, downKey, nil
+}
+
+// readLineageEdges returns the edges leaving the dataset in the given direction
+func readLineageEdges(ctx contractapi.TransactionContextInterface, objectType string, id string) ([]*LineageEdge, error) {
+	it, err := ctx.GetStub().GetStateByPartialCompositeKey(objectType, []string{id})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read lineage of dataset "%s" : %v`, id, err)
+	}
+	defer it.Close()
+
+	edges := []*LineageEdge{}
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		edge := new(LineageEdge)
+		if err := edge.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, nil
+}
+
+// walkLineage collects the graph reachable from the root within depth hops, where a
+// non-positive depth is unbounded. Datasets already visited are not expanded again.
+func walkLineage(ctx contractapi.TransactionContextInterface, objectType string, root string, depth int) (*LineageGraph, error) {
+	graph := &LineageGraph{Root: root, Nodes: []string{root}, Edges: []*LineageEdge{}}
+	frontier := []string{root}
+	for hop := 0; len(frontier) > 0 && (depth <= 0 || hop < depth); hop++ {
+		next := []string{}
+		for _, id := range frontier {
+			edges, err := readLineageEdges(ctx, objectType, id)
+			if err != nil {
+				return nil, err
+			}
+			for _, edge := range edges {
+				graph.Edges = append(graph.Edges, edge)
+				neighbour := edge.To
+				if objectType == downstreamObjectType {
+					neighbour = edge.From
+				}
+				if !containsString(graph.Nodes, neighbour) {
+					graph.Nodes = append(graph.Nodes, neighbour)
+					next = append(next, neighbour)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return graph, nil
+}
+
+// requirePublicID checks that the ID of a dataset is already disclosed on the public ledger, by a
+// public copy or a public tombstone. Datasets held in private or named collections only are
+// rejected, as a lineage edge would reveal their IDs to every organisation.
+func requirePublicID(ctx contractapi.TransactionContextInterface, id string) error {
+	bs, err := readFromPublic(ctx, id)
+	if err != nil {
+		return err
+	}
+	if bs != nil {
+		return nil
+	}
+
+	tombstone, err := readTombstone(ctx, "", id)
+	if err != nil {
+		return err
+	}
+	if tombstone != nil {
+		return nil
+	}
+
+	return fmt.Errorf(`Dataset "%s" is not published on the public ledger, lineage can only relate public datasets.`, id)
+}
+
+// AddLineage records that a dataset of the client's organisation relates to another dataset, which
+// may be held by any organisation. Lineage is public, so both datasets must be published on the
+// public ledger.
+func (l *DatasetMetadataLedger) AddLineage(ctx contractapi.TransactionContextInterface, from string, to string, relation string, transformation string) (*LineageEdge, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opAddLineage); err != nil {
+		return nil, err
+	}
+
+	if !containsString(lineageRelations, relation) {
+		return nil, fmt.Errorf(`Unknown lineage relation "%s", expected one of %v.`, relation, lineageRelations)
+	}
+	if from == to {
+		return nil, fmt.Errorf(`Dataset "%s" cannot relate to itself.`, from)
+	}
+
+	if err := requireNotRetired(ctx, implicitPrivateDataCollection(mspID), from); err != nil {
+		return nil, err
+	}
+	reg, err := readRegistration(ctx, mspID, from)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, from, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return nil, err
+	}
+	if err := requirePublicID(ctx, from); err != nil {
+		return nil, err
+	}
+	if err := requirePublicID(ctx, to); err != nil {
+		return nil, err
+	}
+
+	// The graph must stay acyclic, so from may not already be upstream of to
+	upstream, err := walkLineage(ctx, upstreamObjectType, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	if containsString(upstream.Nodes, from) {
+		return nil, fmt.Errorf(`Dataset "%s" is already upstream of "%s".`, from, to)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edge := &LineageEdge{
+		From:           from,
+		To:             to,
+		Relation:       relation,
+		Transformation: transformation,
+		Owner:          mspID,
+		CreatedBy:      clientID,
+		CreatedAt:      ts,
+	}
+	bs, err := edge.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	upKey, downKey, err := lineageKeys(ctx, from, to, relation)
+	if err != nil {
+		return nil, err
+	}
+	if err := createFromPublic(ctx, upKey, bs); err != nil {
+		return nil, err
+	}
+	if err := createFromPublic(ctx, downKey, bs); err != nil {
+		return nil, err
+	}
+
+	return edge, nil
+}
+
+// RemoveLineage drops a relation recorded for a dataset of the client's organisation
+func (l *DatasetMetadataLedger) RemoveLineage(ctx contractapi.TransactionContextInterface, from string, to string, relation string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opAddLineage); err != nil {
+		return err
+	}
+
+	upKey, downKey, err := lineageKeys(ctx, from, to, relation)
+	if err != nil {
+		return err
+	}
+	bs, err := readFromPublic(ctx, upKey)
+	if err != nil {
+		return err
+	}
+	if bs == nil {
+		return fmt.Errorf(`Dataset "%s" has no "%s" relation to "%s".`, from, relation, to)
+	}
+	edge := new(LineageEdge)
+	if err := edge.FromBytes(bs); err != nil {
+		return err
+	}
+	if edge.Owner != mspID {
+		return fmt.Errorf(`Lineage of dataset "%s" is owned by Org "%s", not "%s".`, from, edge.Owner, mspID)
+	}
+
+	if err := deleteFromPublic(ctx, upKey); err != nil {
+		return err
+	}
+	return deleteFromPublic(ctx, downKey)
+}
+
+// GetLineage returns the datasets the dataset stems from, up to depth hops away, or all of them if depth is not positive
+func (l *DatasetMetadataLedger) GetLineage(ctx contractapi.TransactionContextInterface, id string, depth int) (*LineageGraph, error) {
+	if err := requireCertification(ctx, opQueryLineage); err != nil {
+		return nil, err
+	}
+
+	return walkLineage(ctx, upstreamObjectType, id, depth)
+}
+
+// GetDescendants returns every dataset that stems from the dataset
+func (l *DatasetMetadataLedger) GetDescendants(ctx contractapi.TransactionContextInterface, id string) (*LineageGraph, error) {
+	if err := requireCertification(ctx, opQueryLineage); err != nil {
+		return nil, err
+	}
+
+	return walkLineage(ctx, downstreamObjectType, id, 0)
+}
@@ -0,0 +1,87 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+// metadataWithID is the example metadata under another ID
+func metadataWithID(id string) string {
+	return strings.Replace(exampleMetadata, exampleID, id, 1)
+}
+
+func TestAddLineage(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const (
+		raw     = "org1.example.com/raw"
+		clean   = "org1.example.com/clean"
+		report  = "org1.example.com/report"
+		private = "org1.example.com/private"
+	)
+	for _, id := range []string{raw, clean, report} {
+		mustRegister(t, stub, org1Registrar, metadataWithID(id), "")
+	}
+	mustRegister(t, stub, org1Registrar, metadataWithID(private), "publicDataBlockCollection")
+
+	// Steps run in order, so that later ones see the edges of earlier ones
+	steps := []struct {
+		name     string
+		from     string
+		relation string
+		to       string
+		err      string
+	}{
+		{name: "clean from raw", from: clean, relation: relationDerivedFrom, to: raw},
+		{name: "report from clean", from: report, relation: relationDerivedFrom, to: clean},
+		{name: "direct cycle", from: raw, relation: relationDerivedFrom, to: clean, err: `Dataset "org1.example.com/raw" is already upstream of "org1.example.com/clean".`},
+		{name: "indirect cycle", from: raw, relation: relationPartOf, to: report, err: "is already upstream of"},
+		{name: "duplicate", from: clean, relation: relationDerivedFrom, to: raw, err: "key already exists"},
+		{name: "self", from: raw, relation: relationSupersedes, to: raw, err: "cannot relate to itself"},
+		{name: "unknown relation", from: report, relation: "copyOf", to: raw, err: `Unknown lineage relation "copyOf"`},
+		{name: "private source", from: private, relation: relationDerivedFrom, to: raw, err: `Dataset "org1.example.com/private" is not published on the public ledger`},
+		{name: "private target", from: report, relation: relationDerivedFrom, to: private, err: `Dataset "org1.example.com/private" is not published on the public ledger`},
+		{name: "unknown target", from: report, relation: relationDerivedFrom, to: "org2.example.com/none", err: "is not published on the public ledger"},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			_, err := l.AddLineage(as(t, stub, org1Registrar, nil), step.from, step.to, step.relation, "")
+			if step.err != "" {
+				requireError(t, err, step.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddLineage: %v", err)
+			}
+		})
+	}
+
+	t.Run("other organisation", func(t *testing.T) {
+		_, err := l.AddLineage(as(t, stub, org2Registrar, nil), raw, report, relationSupersedes, "")
+		requireError(t, err, `is not registered by Org "Org2MSP"`)
+	})
+
+	t.Run("lineage", func(t *testing.T) {
+		ctx := as(t, stub, org2Reader, nil)
+		tests := []struct {
+			name  string
+			graph func() (*LineageGraph, error)
+			nodes []string
+		}{
+			{name: "upstream", graph: func() (*LineageGraph, error) { return l.GetLineage(ctx, report, 0) }, nodes: []string{report, clean, raw}},
+			{name: "upstream one hop", graph: func() (*LineageGraph, error) { return l.GetLineage(ctx, report, 1) }, nodes: []string{report, clean}},
+			{name: "downstream", graph: func() (*LineageGraph, error) { return l.GetDescendants(ctx, raw) }, nodes: []string{raw, clean, report}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				graph, err := tt.graph()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Join(graph.Nodes, ",") != strings.Join(tt.nodes, ",") {
+					t.Errorf("nodes = %v, want %v", graph.Nodes, tt.nodes)
+				}
+			})
+		}
+	})
+}
@@ -1,61 +1,72 @@
 package contract
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type DatasetMetadata struct {
 	ID string `json:"id"`
 	// Resource
-	Name string `json:"name"`
-	Note string `json:"note"`
+	Name string `json:"name,omitempty"`
+	Note string `json:"note,omitempty"`
 	// Metadata
-	Title                   string   `json:"title"`
-	Description             string   `json:"description"`
-	ContainsSubnationalData bool     `json:"containsSubnationalData"`
-	Source                  string   `json:"source"`
-	Organisation            string   `json:"organisation"`
-	Maintainer              string   `json:"maintainer"`
-	Date                    string   `json:"date"`
-	Location                string   `json:"location"`
-	FieldNames              []string `json:"fieldNames"`
-	FileTypes               []string `json:"fileTypes"`
-	NumberOfRows            int      `json:"numberOfRows"`
-	License                 string   `json:"license"`
-	DefineLicense           string   `json:"defineLicense"`
-	Methodology             string   `json:"methodology"`
-	DefineMethodology       string   `json:"defineMethodology"`
-	UpdateFrequency         string   `json:"updateFrequency"`
-	Comments                string   `json:"comments"`
-	Tags                    []string `json:"tags"`
+	Title                   string   `json:"title,omitempty"`
+	Description             string   `json:"description,omitempty"`
+	ContainsSubnationalData bool     `json:"containsSubnationalData,omitempty"`
+	Source                  string   `json:"source,omitempty"`
+	Organisation            string   `json:"organisation,omitempty"`
+	Maintainer              string   `json:"maintainer,omitempty"`
+	Date                    string   `json:"date,omitempty"`
+	Location                string   `json:"location,omitempty"`
+	FieldNames              []string `json:"fieldNames,omitempty"`
+	FileTypes               []string `json:"fileTypes,omitempty"`
+	NumberOfRows            int      `json:"numberOfRows,omitempty"`
+	License                 string   `json:"license,omitempty"`
+	DefineLicense           string   `json:"defineLicense,omitempty"`
+	Methodology             string   `json:"methodology,omitempty"`
+	DefineMethodology       string   `json:"defineMethodology,omitempty"`
+	UpdateFrequency         string   `json:"updateFrequency,omitempty"`
+	Comments                string   `json:"comments,omitempty"`
+	Tags                    []string `json:"tags,omitempty"`
+	// Column schema, kept in full with the private metadata
+	Columns []ColumnSchema `json:"columns,omitempty"`
 	// External access endpoint
-	Endpoint string `json:"endpoint"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// Digest of the content behind the endpoint
+	Content *DatasetContent `json:"content,omitempty"`
 }
 
-type DatasetMetadataPublic struct {
-	ID string `json:"id"`
-	// Resource
+// DatasetContent identifies the file at the endpoint, digests are lowercase hex
+type DatasetContent struct {
+	SHA256 string `json:"sha256"`
+	// Root of a binary Merkle tree over the SHA-256 of each chunk
+	MerkleRoot string `json:"merkleRoot,omitempty"`
+	ChunkSize  int64  `json:"chunkSize,omitempty"`
+	Size       int64  `json:"size"`
+}
+
+// ColumnSchema describes one column of a tabular dataset
+type ColumnSchema struct {
 	Name string `json:"name"`
-	Note string `json:"note"`
-	// Metadata
-	Title                   string `json:"title"`
-	Description             string `json:"description"`
-	ContainsSubnationalData bool   `json:"containsSubnationalData"`
-	Source                  string `json:"source"`
-	Organisation            string `json:"organisation"`
-	Maintainer              string `json:"maintainer"`
-	Date                    string `json:"date"`
-	Location                string `json:"location"`
-	NumberOfRows            int    `json:"numberOfRows"`
-	License                 string `json:"license"`
-	DefineLicense           string `json:"defineLicense"`
-	Methodology             string `json:"methodology"`
-	DefineMethodology       string `json:"defineMethodology"`
-	UpdateFrequency         string `json:"updateFrequency"`
-	Comments                string `json:"comments"`
+	// One of columnTypes
+	Type        string `json:"type"`
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+	Nullable    bool   `json:"nullable"`
+	// PII columns are left out of every public projection
+	PII bool `json:"pii"`
 }
 
+// DatasetMetadataPublic is the projection of DatasetMetadata written to a public collection,
+// fields left out by the disclosure policy of the owner are omitted
+type DatasetMetadataPublic = DatasetMetadata
+
 type DatasetMetadataInterface interface {
 	ToBytes() ([]byte, error)
 	FromBytes(bs []byte) error
@@ -72,36 +83,135 @@ func (md *DatasetMetadata) ToBytes() ([]byte, error) {
 }
 
 func (md *DatasetMetadata) FromBytes(bs []byte) error {
-	err := json.Unmarshal(bs, md)
-	if err != nil {
+	return decodeStrict(bs, md)
+}
+
+func (md *DatasetMetadata) Validate() error {
+	errs := ValidationErrors{}
+	errs.checkCommon(md.ID, md.Date, md.NumberOfRows, md.License, md.DefineLicense)
+	if md.Endpoint != "" {
+		if u, err := url.Parse(md.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs.add("endpoint", fmt.Sprintf(`"%s" is not an absolute URL`, md.Endpoint))
+		}
+	}
+	errs.checkColumns(md.FieldNames, md.Columns)
+	if md.Content != nil {
+		if !sha256Pattern.MatchString(md.Content.SHA256) {
+			errs.add("content.sha256", fmt.Sprintf(`"%s" is not a hex SHA-256 digest`, md.Content.SHA256))
+		}
+		if md.Content.MerkleRoot != "" {
+			if !sha256Pattern.MatchString(md.Content.MerkleRoot) {
+				errs.add("content.merkleRoot", fmt.Sprintf(`"%s" is not a hex SHA-256 digest`, md.Content.MerkleRoot))
+			}
+			if md.Content.ChunkSize <= 0 {
+				errs.add("content.chunkSize", "required with merkleRoot")
+			}
+		}
+		if md.Content.Size < 0 {
+			errs.add("content.size", fmt.Sprintf("must not be negative, got %d", md.Content.Size))
+		}
+	}
+
+	return errs.orNil()
+}
+
+// decodeStrict rejects JSON fields unknown to the metadata
+func decodeStrict(bs []byte, md interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(md); err != nil {
 		return fmt.Errorf("Failed to decode metadata.\n%v", err)
 	}
+	if dec.More() {
+		return fmt.Errorf("Failed to decode metadata.\nunexpected data after the metadata object")
+	}
 
 	return nil
 }
 
-func (md *DatasetMetadata) Validate() error {
-	return nil
+// e.g. "org1.example.com/data001"
+var datasetIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*/[A-Za-z0-9._~-]+(/[A-Za-z0-9._~-]+)*$`)
+
+var columnTypes = []string{"string", "integer", "number", "boolean", "date", "datetime"}
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// SPDX and Open Data Commons identifiers accepted as license, "other" requires defineLicense
+var licenseWhitelist = []string{
+	"ODC-PDDL", "ODC-PDDL-1.0", "ODC-By", "ODC-By-1.0", "ODbL", "ODbL-1.0", "PDDL-1.0",
+	"CC0-1.0", "CC-BY-4.0", "CC-BY-SA-4.0", "CC-BY-NC-4.0", "CC-BY-ND-4.0", "CC-BY-NC-SA-4.0",
+	"Apache-2.0", "MIT", "GPL-3.0-only", "other",
 }
 
-func (md *DatasetMetadataPublic) ToBytes() ([]byte, error) {
-	bs, err := json.Marshal(*md)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to encode metadata to bytes.\n%v", err)
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors reports every invalid field at once
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = fmt.Sprintf("%s: %s", e.Field, e.Message)
 	}
+	return fmt.Sprintf("Invalid metadata : %s", strings.Join(msgs, "; "))
+}
 
-	return bs, nil
+func (errs *ValidationErrors) add(field string, message string) {
+	*errs = append(*errs, ValidationError{Field: field, Message: message})
 }
 
-func (md *DatasetMetadataPublic) FromBytes(bs []byte) error {
-	err := json.Unmarshal(bs, md)
-	if err != nil {
-		return fmt.Errorf("Failed to decode metadata.\n%v", err)
+func (errs ValidationErrors) orNil() error {
+	if len(errs) == 0 {
+		return nil
 	}
+	return errs
+}
 
-	return nil
+func (errs *ValidationErrors) checkCommon(id string, date string, numberOfRows int, license string, defineLicense string) {
+	if id == "" {
+		errs.add("id", "required")
+	} else if !datasetIDPattern.MatchString(id) {
+		errs.add("id", fmt.Sprintf(`"%s" is not of the form "org/path"`, id))
+	}
+
+	if date == "" {
+		errs.add("date", "required")
+	} else if _, err := time.Parse(time.RFC3339, date); err != nil {
+		errs.add("date", fmt.Sprintf(`"%s" is not an RFC3339 timestamp`, date))
+	}
+
+	if numberOfRows < 0 {
+		errs.add("numberOfRows", fmt.Sprintf("must not be negative, got %d", numberOfRows))
+	}
+
+	if license != "" && !containsString(licenseWhitelist, license) {
+		errs.add("license", fmt.Sprintf(`"%s" is not one of [%s]`, license, strings.Join(licenseWhitelist, ", ")))
+	}
+	if license == "other" && defineLicense == "" {
+		errs.add("defineLicense", `required when license is "other"`)
+	}
 }
 
-func (md *DatasetMetadataPublic) Validate() error {
-	return nil
+// checkColumns requires unique, typed columns which, if fieldNames is given too, match it in order
+func (errs *ValidationErrors) checkColumns(fieldNames []string, columns []ColumnSchema) {
+	names := []string{}
+	for i, c := range columns {
+		field := fmt.Sprintf("columns[%d]", i)
+		if c.Name == "" {
+			errs.add(field+".name", "required")
+		} else if containsString(names, c.Name) {
+			errs.add(field+".name", fmt.Sprintf(`"%s" appears more than once`, c.Name))
+		}
+		names = append(names, c.Name)
+		if !containsString(columnTypes, c.Type) {
+			errs.add(field+".type", fmt.Sprintf(`"%s" is not one of [%s]`, c.Type, strings.Join(columnTypes, ", ")))
+		}
+	}
+
+	if len(columns) > 0 && len(fieldNames) > 0 && strings.Join(fieldNames, "\x00") != strings.Join(names, "\x00") {
+		errs.add("fieldNames", "must list the names of columns in the same order")
+	}
 }
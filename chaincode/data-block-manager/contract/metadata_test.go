@@ -0,0 +1,95 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *DatasetMetadata {
+		return &DatasetMetadata{ID: exampleID, Date: "2022-01-01T09:00:00Z", License: "CC-BY-4.0"}
+	}
+	digest := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name   string
+		modify func(md *DatasetMetadata)
+		errs   []string
+	}{
+		{name: "valid", modify: func(md *DatasetMetadata) {}},
+		{name: "missing id", modify: func(md *DatasetMetadata) { md.ID = "" }, errs: []string{"id: required"}},
+		{name: "id without path", modify: func(md *DatasetMetadata) { md.ID = "data001" }, errs: []string{`id: "data001" is not of the form "org/path"`}},
+		{name: "missing date", modify: func(md *DatasetMetadata) { md.Date = "" }, errs: []string{"date: required"}},
+		{name: "date not RFC3339", modify: func(md *DatasetMetadata) { md.Date = "2022-01-01" }, errs: []string{`date: "2022-01-01" is not an RFC3339 timestamp`}},
+		{name: "negative rows", modify: func(md *DatasetMetadata) { md.NumberOfRows = -1 }, errs: []string{"numberOfRows: must not be negative, got -1"}},
+		{name: "unknown license", modify: func(md *DatasetMetadata) { md.License = "WTFPL" }, errs: []string{`license: "WTFPL" is not one of`}},
+		{name: "other license undefined", modify: func(md *DatasetMetadata) { md.License = "other" }, errs: []string{`defineLicense: required when license is "other"`}},
+		{name: "other license defined", modify: func(md *DatasetMetadata) { md.License, md.DefineLicense = "other", "https://example.com/license" }},
+		{name: "relative endpoint", modify: func(md *DatasetMetadata) { md.Endpoint = "/data.csv" }, errs: []string{`endpoint: "/data.csv" is not an absolute URL`}},
+		{
+			name: "columns",
+			modify: func(md *DatasetMetadata) {
+				md.FieldNames = []string{"a", "b"}
+				md.Columns = []ColumnSchema{{Name: "a", Type: "integer"}, {Name: "b", Type: "date", PII: true}}
+			},
+		},
+		{
+			name: "invalid columns",
+			modify: func(md *DatasetMetadata) {
+				md.Columns = []ColumnSchema{{Name: "a", Type: "integer"}, {Name: "a", Type: "text"}, {Type: "string"}}
+			},
+			errs: []string{`columns[1].name: "a" appears more than once`, `columns[1].type: "text" is not one of`, "columns[2].name: required"},
+		},
+		{
+			name: "columns out of order",
+			modify: func(md *DatasetMetadata) {
+				md.FieldNames = []string{"b", "a"}
+				md.Columns = []ColumnSchema{{Name: "a", Type: "integer"}, {Name: "b", Type: "string"}}
+			},
+			errs: []string{"fieldNames: must list the names of columns in the same order"},
+		},
+		{name: "content", modify: func(md *DatasetMetadata) {
+			md.Content = &DatasetContent{SHA256: digest, MerkleRoot: digest, ChunkSize: 1024, Size: 2048}
+		}},
+		{
+			name:   "invalid content",
+			modify: func(md *DatasetMetadata) { md.Content = &DatasetContent{SHA256: "AB", MerkleRoot: digest, Size: -1} },
+			errs:   []string{`content.sha256: "AB" is not a hex SHA-256 digest`, "content.chunkSize: required with merkleRoot", "content.size: must not be negative, got -1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := valid()
+			tt.modify(md)
+			err := md.Validate()
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("expected valid metadata, got %v", err)
+				}
+				return
+			}
+			for _, want := range tt.errs {
+				requireError(t, err, want)
+			}
+			if errs := err.(ValidationErrors); len(errs) != len(tt.errs) {
+				t.Errorf("got %d errors, want %d : %v", len(errs), len(tt.errs), err)
+			}
+		})
+	}
+}
+
+func TestFromBytesRejectsUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{name: "unknown field", json: `{"id": "org1.example.com/data001", "owner": "Org1MSP"}`, err: `unknown field "owner"`},
+		{name: "trailing data", json: `{"id": "org1.example.com/data001"} {}`, err: "unexpected data after the metadata object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, new(DatasetMetadata).FromBytes([]byte(tt.json)), tt.err)
+		})
+	}
+}
@@ -2,7 +2,9 @@ package contract
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
@@ -34,6 +36,25 @@ func getClientMSPID(ctx contractapi.TransactionContextInterface) (string, error)
 	return id, nil
 }
 
+// OU roles are the organisational units of the client certificate, e.g. "client" or "admin"
+func hasAnyOURole(ctx contractapi.TransactionContextInterface, roles []string) (bool, error) {
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return false, fmt.Errorf("Failed to retrieve certificate from the client : %v", err)
+	}
+	if cert == nil {
+		return false, nil
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if containsString(roles, ou) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func getPeerMSPID() (string, error) {
 	id, err := shim.GetMSPID()
 	if err != nil {
@@ -43,6 +64,15 @@ func getPeerMSPID() (string, error) {
 	return id, nil
 }
 
+func createCompositeKey(ctx contractapi.TransactionContextInterface, objectType string, attributes ...string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", fmt.Errorf(`Failed to create composite key of type "%s" : %v`, objectType, err)
+	}
+
+	return key, nil
+}
+
 func getTxTransient(ctx contractapi.TransactionContextInterface) (map[string][]byte, error) {
 	map_, err := ctx.GetStub().GetTransient()
 	if err != nil {
@@ -52,6 +82,19 @@ func getTxTransient(ctx contractapi.TransactionContextInterface) (map[string][]b
 	return map_, nil
 }
 
+// emitEvent sets the chaincode event of the transaction, a transaction keeps its last event only
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, payload interface{}) error {
+	bs, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf(`Failed to encode payload of event "%s" : %v`, name, err)
+	}
+	if err := ctx.GetStub().SetEvent(name, bs); err != nil {
+		return fmt.Errorf(`Failed to set event "%s" : %v`, name, err)
+	}
+
+	return nil
+}
+
 func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error) {
 	ts, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
@@ -61,6 +104,15 @@ func getTxTimestamp(ctx contractapi.TransactionContextInterface) (string, error)
 	return ts.AsTime().String(), nil
 }
 
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to retrieve timestamp from current transaction context : %v", err)
+	}
+
+	return ts.AsTime(), nil
+}
+
 func requireIdenticalMSPID(ctx contractapi.TransactionContextInterface, mspID *string) error {
 	clientMSPID, err := getClientMSPID(ctx)
 	if err != nil {
@@ -83,12 +135,63 @@ func requireIdenticalMSPID(ctx contractapi.TransactionContextInterface, mspID *s
 	return nil
 }
 
-func requireCertification(ctx contractapi.TransactionContextInterface, cond any) error {
-	/**
-		TODO
-		verify certification to grant access to enable:
-		- register, update ledger
-		- access private collection
-	**/
-	return nil
+// the registering client, or an admin of the registering organisation, may modify a dataset
+func requireOwnership(ctx contractapi.TransactionContextInterface, reg *DatasetRegistration) error {
+	clientMSPID, err := getClientMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	if clientMSPID != reg.Owner {
+		return fmt.Errorf(`Client from Org "%s" is not the owner of dataset "%s".`, clientMSPID, reg.ID)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	if clientID == reg.Registrar {
+		return nil
+	}
+
+	isAdmin, err := hasAnyOURole(ctx, []string{"admin"})
+	if err != nil {
+		return err
+	}
+	if isAdmin {
+		return nil
+	}
+
+	return fmt.Errorf(`Client "%s" is neither the registrar of dataset "%s" nor an admin of Org "%s".`, clientID, reg.ID, reg.Owner)
+}
+
+// requireCertification checks the client certificate against the access policy its organisation
+// set for the operation
+func requireCertification(ctx contractapi.TransactionContextInterface, operation string) error {
+	mspID, err := getClientMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	policy, err := readAccessPolicy(ctx, mspID, operation)
+	if err != nil {
+		return err
+	}
+
+	return evaluateAccessPolicy(ctx, policy)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// notFoundCode prefixes the messages of datasets that cannot be read because they are missing,
+// retired or expired, so that clients can tell them apart from other failures
+const notFoundCode = "DATASET_NOT_FOUND"
+
+func notFoundError(format string, a ...interface{}) error {
+	return fmt.Errorf(notFoundCode+": "+format, a...)
 }
@@ -0,0 +1,287 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const policyObjectType = "policy"
+
+// operations guarded by requireCertification
+const (
+	opRegister      = "Register"
+	opUpdate        = "Update"
+	opDeregister    = "Deregister"
+	opQuery         = "Query"
+	opQueryPrivate  = "QueryPrivate"
+	opQueryRevision = "QueryRevision"
+	opQueryByRange  = "QueryByRange"
+	opQueryByIndex  = "QueryByIndex"
+	opSearch        = "Search"
+	opSetPolicy     = "SetPolicy"
+	opSetSchema     = "SetSchema"
+
+	opSetDisclosurePolicy = "SetDisclosurePolicy"
+	opRequestAccess       = "RequestAccess"
+	opGrantAccess         = "GrantAccess"
+	opQueryAccess         = "QueryAccess"
+	opVerifyDataset       = "VerifyDataset"
+	opAddLineage          = "AddLineage"
+	opQueryLineage        = "QueryLineage"
+	opPurgeExpired        = "PurgeExpired"
+	opSetCollection       = "SetCollection"
+	opRecordAccess        = "RecordAccess"
+	opManageProduct       = "ManageDataProduct"
+)
+
+// AccessRule is satisfied when the client matches every non-empty condition
+type AccessRule struct {
+	// MSP IDs allowed, any MSP if empty
+	MSPIDs []string `json:"mspIDs,omitempty"`
+	// OU roles of the client certificate, e.g. "client" or "admin", any of which suffices
+	OURoles []string `json:"ouRoles,omitempty"`
+	// X.509 attributes required, where an empty value only requires the attribute to be present
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// AccessPolicy grants an operation to clients of its owner when any of its rules is satisfied
+type AccessPolicy struct {
+	// MSP ID of the organisation whose clients the policy applies to
+	Owner     string       `json:"owner"`
+	Operation string       `json:"operation"`
+	Rules     []AccessRule `json:"rules"`
+}
+
+var (
+	adminRule     = AccessRule{OURoles: []string{"admin"}}
+	registrarRule = AccessRule{Attributes: map[string]string{"dataset.registrar": "true"}}
+	readerRule    = AccessRule{Attributes: map[string]string{"dataset.reader": ""}}
+
+	registrarRules = []AccessRule{registrarRule, adminRule}
+	readerRules    = []AccessRule{readerRule, registrarRule, adminRule}
+)
+
+// defaultPolicies apply until an organisation stores its own policy for the operation
+var defaultPolicies = map[string][]AccessRule{
+	opRegister:      registrarRules,
+	opUpdate:        registrarRules,
+	opDeregister:    registrarRules,
+	opQuery:         readerRules,
+	opQueryPrivate:  registrarRules,
+	opQueryRevision: registrarRules,
+	opQueryByRange:  readerRules,
+	opQueryByIndex:  readerRules,
+	opSearch:        readerRules,
+	opSetPolicy:     {adminRule},
+	opSetSchema:     {adminRule},
+
+	opSetDisclosurePolicy: {adminRule},
+	opRequestAccess:       readerRules,
+	opGrantAccess:         registrarRules,
+	opQueryAccess:         readerRules,
+	opVerifyDataset:       readerRules,
+	opAddLineage:          registrarRules,
+	opQueryLineage:        readerRules,
+	opPurgeExpired:        registrarRules,
+	opSetCollection:       {adminRule},
+	opRecordAccess:        readerRules,
+	opManageProduct:       registrarRules,
+}
+
+func (p *AccessPolicy) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*p)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode policy to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (p *AccessPolicy) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, p)
+	if err != nil {
+		return fmt.Errorf("Failed to decode policy.\n%v", err)
+	}
+
+	return nil
+}
+
+func (p *AccessPolicy) Validate() error {
+	if _, ok := defaultPolicies[p.Operation]; !ok {
+		return fmt.Errorf(`Unknown operation "%s" in policy.`, p.Operation)
+	}
+	if len(p.Rules) == 0 {
+		return fmt.Errorf(`Policy of operation "%s" defines no rule.`, p.Operation)
+	}
+	for i, rule := range p.Rules {
+		for _, mspID := range rule.MSPIDs {
+			if mspID != p.Owner {
+				return fmt.Errorf(`Rule %d of operation "%s" names Org "%s", a policy of Org "%s" only applies to its own clients.`, i, p.Operation, mspID, p.Owner)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r AccessRule) String() string {
+	conds := []string{}
+	if len(r.MSPIDs) > 0 {
+		conds = append(conds, fmt.Sprintf("MSP in [%s]", strings.Join(r.MSPIDs, ", ")))
+	}
+	if len(r.OURoles) > 0 {
+		conds = append(conds, fmt.Sprintf("OU in [%s]", strings.Join(r.OURoles, ", ")))
+	}
+	names := make([]string, 0, len(r.Attributes))
+	for name := range r.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := r.Attributes[name]; value != "" {
+			conds = append(conds, fmt.Sprintf("%s=%s", name, value))
+		} else {
+			conds = append(conds, fmt.Sprintf("%s present", name))
+		}
+	}
+	if len(conds) == 0 {
+		return "any client"
+	}
+
+	return strings.Join(conds, " and ")
+}
+
+func (r AccessRule) satisfiedBy(ctx contractapi.TransactionContextInterface) (bool, error) {
+	if len(r.MSPIDs) > 0 {
+		mspID, err := getClientMSPID(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !containsString(r.MSPIDs, mspID) {
+			return false, nil
+		}
+	}
+
+	if len(r.OURoles) > 0 {
+		ok, err := hasAnyOURole(ctx, r.OURoles)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	for name, expected := range r.Attributes {
+		value, found, err := ctx.GetClientIdentity().GetAttributeValue(name)
+		if err != nil {
+			return false, fmt.Errorf(`Failed to retrieve attribute "%s" from the client : %v`, name, err)
+		}
+		if !found || (expected != "" && value != expected) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func policyKey(ctx contractapi.TransactionContextInterface, mspID string, operation string) (string, error) {
+	return createCompositeKey(ctx, policyObjectType, mspID, operation)
+}
+
+// readAccessPolicy falls back to the default policy if the organisation has not stored one for the operation
+func readAccessPolicy(ctx contractapi.TransactionContextInterface, mspID string, operation string) (*AccessPolicy, error) {
+	rules, ok := defaultPolicies[operation]
+	if !ok {
+		return nil, fmt.Errorf(`Unknown operation "%s".`, operation)
+	}
+
+	key, err := policyKey(ctx, mspID, operation)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return &AccessPolicy{Owner: mspID, Operation: operation, Rules: rules}, nil
+	}
+
+	policy := new(AccessPolicy)
+	if err := policy.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func evaluateAccessPolicy(ctx contractapi.TransactionContextInterface, policy *AccessPolicy) error {
+	for _, rule := range policy.Rules {
+		ok, err := rule.satisfiedBy(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return err
+	}
+	mspID, err := getClientMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	rules := make([]string, len(policy.Rules))
+	for i, rule := range policy.Rules {
+		rules[i] = "(" + rule.String() + ")"
+	}
+
+	return fmt.Errorf(`Client "%s" from Org "%s" is denied "%s" : requires %s`, clientID, mspID, policy.Operation, strings.Join(rules, " or "))
+}
+
+// SetPolicy replaces the access policy of an operation for the clients of the client's organisation,
+// e.g. '{"operation":"Register","rules":[{"attributes":{"dataset.registrar":"true"}}]}'. Other
+// organisations keep their own policies.
+func (l *DatasetMetadataLedger) SetPolicy(ctx contractapi.TransactionContextInterface, policyJSON string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opSetPolicy); err != nil {
+		return err
+	}
+
+	policy := new(AccessPolicy)
+	if err := policy.FromBytes([]byte(policyJSON)); err != nil {
+		return err
+	}
+	policy.Owner = mspID
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	bs, err := policy.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	key, err := policyKey(ctx, mspID, policy.Operation)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return fmt.Errorf(`Failed to write policy of operation "%s" of Org "%s" : %v`, policy.Operation, mspID, err)
+	}
+
+	return nil
+}
+
+// GetPolicy returns the effective access policy of an operation for the clients of an organisation
+func (l *DatasetMetadataLedger) GetPolicy(ctx contractapi.TransactionContextInterface, mspID string, operation string) (*AccessPolicy, error) {
+	return readAccessPolicy(ctx, mspID, operation)
+}
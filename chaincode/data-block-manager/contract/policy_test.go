@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestRequireCertificationDefaults(t *testing.T) {
+	stub := newTestStub()
+	tests := []struct {
+		name      string
+		id        *testIdentity
+		operation string
+		allowed   bool
+	}{
+		{name: "registrar registers", id: org1Registrar, operation: opRegister, allowed: true},
+		{name: "reader registers", id: org1Reader, operation: opRegister},
+		{name: "reader queries", id: org1Reader, operation: opQuery, allowed: true},
+		{name: "admin queries", id: org1Admin, operation: opQuery, allowed: true},
+		{name: "registrar sets policy", id: org1Registrar, operation: opSetPolicy},
+		{name: "admin sets policy", id: org1Admin, operation: opSetPolicy, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := requireCertification(as(t, stub, tt.id, nil), tt.operation)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected access, got %v", err)
+			}
+			if !tt.allowed {
+				requireError(t, err, `is denied "`+tt.operation+`"`)
+			}
+		})
+	}
+}
+
+func TestSetPolicyIsScopedToOrganisation(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+
+	// Org1 restricts registration to its admins
+	if err := l.SetPolicy(as(t, stub, org1Admin, nil), `{"operation":"Register","rules":[{"ouRoles":["admin"]}]}`); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		id      *testIdentity
+		allowed bool
+	}{
+		{name: "Org1 registrar", id: org1Registrar},
+		{name: "Org1 admin", id: org1Admin, allowed: true},
+		{name: "Org2 registrar", id: org2Registrar, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := requireCertification(as(t, stub, tt.id, nil), opRegister)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected access, got %v", err)
+			}
+			if !tt.allowed {
+				requireError(t, err, `is denied "Register"`)
+			}
+		})
+	}
+
+	t.Run("stored per organisation", func(t *testing.T) {
+		ctx := as(t, stub, org2Reader, nil)
+		policy, err := l.GetPolicy(ctx, "Org1MSP", opRegister)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.Owner != "Org1MSP" || len(policy.Rules) != 1 {
+			t.Errorf("policy of Org1MSP = %+v", policy)
+		}
+		policy, err = l.GetPolicy(ctx, "Org2MSP", opRegister)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.Owner != "Org2MSP" || len(policy.Rules) != len(registrarRules) {
+			t.Errorf("policy of Org2MSP = %+v", policy)
+		}
+	})
+
+	t.Run("other organisation named", func(t *testing.T) {
+		err := l.SetPolicy(as(t, stub, org2Admin, nil), `{"operation":"Register","rules":[{"mspIDs":["Org1MSP"]}]}`)
+		requireError(t, err, `names Org "Org1MSP"`)
+	})
+
+	t.Run("peer of another organisation", func(t *testing.T) {
+		ctx := as(t, stub, org2Admin, nil)
+		t.Setenv("CORE_PEER_LOCALMSPID", "Org1MSP")
+		err := l.SetPolicy(ctx, `{"operation":"Register","rules":[{"ouRoles":["admin"]}]}`)
+		requireError(t, err, `has no access to service from Org "Org1MSP"`)
+	})
+}
@@ -0,0 +1,454 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	productObjectType = "product"
+	// full member list in the implicit collection of the owner
+	productMembersObjectType = "product~members"
+)
+
+// DataProductMember refers to a dataset by its ID and the collection it is read from, where ""
+// stands for the public ledger
+type DataProductMember struct {
+	ID         string `json:"id"`
+	Collection string `json:"collection"`
+}
+
+// DataProduct bundles related datasets, in order, under metadata they share. Products are kept on
+// the public ledger, their members may be held in any collection visible to the owner. Only members
+// on the public ledger are listed there, the others are kept in the implicit collection of the owner.
+type DataProduct struct {
+	ID string `json:"id"`
+	// MSP ID of the owning organisation
+	Owner string `json:"owner"`
+	// Incremented on every change of the product
+	Version int `json:"version"`
+	// Metadata shared by the members
+	Title        string   `json:"title,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Organisation string   `json:"organisation,omitempty"`
+	FieldNames   []string `json:"fieldNames,omitempty"`
+	License      string   `json:"license,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	// Members in order
+	Members   []DataProductMember `json:"members"`
+	CreatedAt string              `json:"createdAt"`
+	UpdatedAt string              `json:"updatedAt"`
+}
+
+func (p *DataProduct) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*p)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode data product to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (p *DataProduct) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, p)
+	if err != nil {
+		return fmt.Errorf("Failed to decode data product.\n%v", err)
+	}
+
+	return nil
+}
+
+// DataProductMembers is the full member list of a product, kept in the implicit collection of the owner
+type DataProductMembers struct {
+	ProductID string              `json:"productID"`
+	Members   []DataProductMember `json:"members"`
+}
+
+func (m *DataProductMembers) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*m)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode data product members to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (m *DataProductMembers) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, m)
+	if err != nil {
+		return fmt.Errorf("Failed to decode data product members.\n%v", err)
+	}
+
+	return nil
+}
+
+func (p *DataProduct) Validate() error {
+	errs := ValidationErrors{}
+	if p.ID == "" {
+		errs.add("id", "required")
+	} else if !datasetIDPattern.MatchString(p.ID) {
+		errs.add("id", fmt.Sprintf(`"%s" is not of the form "org/path"`, p.ID))
+	}
+	if p.License != "" && !containsString(licenseWhitelist, p.License) {
+		errs.add("license", fmt.Sprintf(`"%s" is not one of [%s]`, p.License, strings.Join(licenseWhitelist, ", ")))
+	}
+	seen := []string{}
+	for i, m := range p.Members {
+		if m.ID == "" {
+			errs.add(fmt.Sprintf("members[%d].id", i), "required")
+		} else if containsString(seen, m.ID) {
+			errs.add(fmt.Sprintf("members[%d].id", i), fmt.Sprintf(`"%s" appears more than once`, m.ID))
+		}
+		seen = append(seen, m.ID)
+	}
+
+	return errs.orNil()
+}
+
+func (p *DataProduct) indexOf(datasetID string) int {
+	for i, m := range p.Members {
+		if m.ID == datasetID {
+			return i
+		}
+	}
+	return -1
+}
+
+// public returns the product with the members on the public ledger only
+func (p *DataProduct) public() *DataProduct {
+	public := *p
+	public.Members = []DataProductMember{}
+	for _, m := range p.Members {
+		if m.Collection == "" {
+			public.Members = append(public.Members, m)
+		}
+	}
+	return &public
+}
+
+func productKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return createCompositeKey(ctx, productObjectType, id)
+}
+
+func productMembersKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return createCompositeKey(ctx, productMembersObjectType, id)
+}
+
+// readProduct returns nil if the product does not exist
+func readProduct(ctx contractapi.TransactionContextInterface, id string) (*DataProduct, error) {
+	key, err := productKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	product := new(DataProduct)
+	if err := product.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// writeProduct keeps the full member list in the implicit collection of the owner and the product
+// with its public members on the public ledger, which it returns
+func writeProduct(ctx contractapi.TransactionContextInterface, product *DataProduct) (*DataProduct, error) {
+	membersKey, err := productMembersKey(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	members := &DataProductMembers{ProductID: product.ID, Members: product.Members}
+	membersAsBytes, err := members.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitPrivateDataCollection(product.Owner), membersKey, membersAsBytes); err != nil {
+		return nil, fmt.Errorf(`Failed to write members of data product "%s" : %v`, product.ID, err)
+	}
+
+	public := product.public()
+	key, err := productKey(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := public.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to write data product "%s" : %v`, product.ID, err)
+	}
+
+	return public, nil
+}
+
+// readOwnMembers replaces the public members of a product of the client's organisation with its full
+// member list, when read from a peer of the organisation. Other products are left as they are.
+func readOwnMembers(ctx contractapi.TransactionContextInterface, product *DataProduct) error {
+	clientMSPID, err := getClientMSPID(ctx)
+	if err != nil {
+		return err
+	}
+	peerMSPID, err := getPeerMSPID()
+	if err != nil {
+		return err
+	}
+	if product.Owner != clientMSPID || peerMSPID != clientMSPID {
+		return nil
+	}
+
+	key, err := productMembersKey(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	bs, err := readFromCollection(ctx, implicitPrivateDataCollection(product.Owner), key)
+	if err != nil {
+		return err
+	}
+	if bs == nil {
+		return fmt.Errorf(`Members of data product "%s" not found in the implicit collection of Org "%s".`, product.ID, product.Owner)
+	}
+	members := new(DataProductMembers)
+	if err := members.FromBytes(bs); err != nil {
+		return err
+	}
+	product.Members = members.Members
+
+	return nil
+}
+
+// readOwnedProduct returns the product with its full member list if it is owned by the organisation
+func readOwnedProduct(ctx contractapi.TransactionContextInterface, mspID string, id string) (*DataProduct, error) {
+	product, err := readProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, fmt.Errorf(`Data product "%s" does not exist.`, id)
+	}
+	if product.Owner != mspID {
+		return nil, fmt.Errorf(`Data product "%s" is owned by Org "%s".`, id, product.Owner)
+	}
+	if err := readOwnMembers(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// requireVisibleMember checks that the member exists in its collection and that the organisation can
+// read it there, i.e. the collection is the public ledger, its own implicit collection or one it is a
+// member of
+func requireVisibleMember(ctx contractapi.TransactionContextInterface, mspID string, member DataProductMember) error {
+	if member.Collection != "" && member.Collection != implicitPrivateDataCollection(mspID) {
+		if err := requireCollectionMember(ctx, mspID, member.Collection); err != nil {
+			return err
+		}
+	}
+	if err := requireNotRetired(ctx, member.Collection, member.ID); err != nil {
+		return err
+	}
+	if err := requireNotExpired(ctx, member.Collection, member.ID); err != nil {
+		return err
+	}
+
+	bs, err := readFromTarget(ctx, member.Collection, member.ID)
+	if err != nil {
+		return err
+	}
+	if bs == nil {
+		return fmt.Errorf(`Dataset "%s" does not exist in collection "%s".`, member.ID, member.Collection)
+	}
+
+	return nil
+}
+
+// CreateDataProduct creates a data product of the client's organisation from the JSON document,
+// whose members must all exist and be visible to the organisation. It returns the product as kept on
+// the public ledger.
+func (l *DatasetMetadataLedger) CreateDataProduct(ctx contractapi.TransactionContextInterface, productJSON string) (*DataProduct, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opManageProduct); err != nil {
+		return nil, err
+	}
+
+	product := new(DataProduct)
+	dec := json.NewDecoder(bytes.NewReader([]byte(productJSON)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(product); err != nil {
+		return nil, fmt.Errorf("Failed to decode data product.\n%v", err)
+	}
+	if product.Members == nil {
+		product.Members = []DataProductMember{}
+	}
+	if err := product.Validate(); err != nil {
+		return nil, err
+	}
+
+	prev, err := readProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		return nil, fmt.Errorf(`Data product "%s" already exists.`, product.ID)
+	}
+	for _, m := range product.Members {
+		if err := requireVisibleMember(ctx, mspID, m); err != nil {
+			return nil, err
+		}
+	}
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product.Owner = mspID
+	product.Version = 1
+	product.CreatedAt = ts
+	product.UpdatedAt = ts
+
+	return writeProduct(ctx, product)
+}
+
+// AddToDataProduct inserts a dataset into a data product of the client's organisation at the given
+// position, a negative or out of range position appends it. Positions count every member, including
+// those held in collections.
+func (l *DatasetMetadataLedger) AddToDataProduct(ctx contractapi.TransactionContextInterface, productID string, datasetID string, collection string, position int) (*DataProduct, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opManageProduct); err != nil {
+		return nil, err
+	}
+
+	product, err := readOwnedProduct(ctx, mspID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.indexOf(datasetID) >= 0 {
+		return nil, fmt.Errorf(`Dataset "%s" is already a member of data product "%s".`, datasetID, productID)
+	}
+	member := DataProductMember{ID: datasetID, Collection: collection}
+	if err := requireVisibleMember(ctx, mspID, member); err != nil {
+		return nil, err
+	}
+
+	if position < 0 || position > len(product.Members) {
+		position = len(product.Members)
+	}
+	members := append([]DataProductMember{}, product.Members[:position]...)
+	members = append(members, member)
+	product.Members = append(members, product.Members[position:]...)
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product.Version++
+	product.UpdatedAt = ts
+
+	return writeProduct(ctx, product)
+}
+
+// RemoveFromDataProduct removes a dataset from a data product of the client's organisation
+func (l *DatasetMetadataLedger) RemoveFromDataProduct(ctx contractapi.TransactionContextInterface, productID string, datasetID string) (*DataProduct, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opManageProduct); err != nil {
+		return nil, err
+	}
+
+	product, err := readOwnedProduct(ctx, mspID, productID)
+	if err != nil {
+		return nil, err
+	}
+	i := product.indexOf(datasetID)
+	if i < 0 {
+		return nil, fmt.Errorf(`Dataset "%s" is not a member of data product "%s".`, datasetID, productID)
+	}
+	product.Members = append(product.Members[:i], product.Members[i+1:]...)
+
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	product.Version++
+	product.UpdatedAt = ts
+
+	return writeProduct(ctx, product)
+}
+
+// GetDataProduct returns a data product with its members in order. Members held in collections are
+// only listed to the owning organisation.
+func (l *DatasetMetadataLedger) GetDataProduct(ctx contractapi.TransactionContextInterface, productID string) (*DataProduct, error) {
+	if err := requireCertification(ctx, opQuery); err != nil {
+		return nil, err
+	}
+
+	product, err := readProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, fmt.Errorf(`Data product "%s" does not exist.`, productID)
+	}
+	if err := readOwnMembers(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// ListDataProducts returns the data products on the ledger, only those of the given organisation
+// if owner is not empty
+func (l *DatasetMetadataLedger) ListDataProducts(ctx contractapi.TransactionContextInterface, owner string) ([]*DataProduct, error) {
+	if err := requireCertification(ctx, opQuery); err != nil {
+		return nil, err
+	}
+
+	it, err := ctx.GetStub().GetStateByPartialCompositeKey(productObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read data products : %v", err)
+	}
+	defer it.Close()
+
+	products := []*DataProduct{}
+	for it.HasNext() {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		product := new(DataProduct)
+		if err := product.FromBytes(item.Value); err != nil {
+			return nil, err
+		}
+		if owner != "" && product.Owner != owner {
+			continue
+		}
+		if err := readOwnMembers(ctx, product); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,167 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+func memberIDs(product *DataProduct) string {
+	ids := []string{}
+	for _, m := range product.Members {
+		ids = append(ids, m.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestDataProduct(t *testing.T) {
+	const (
+		collection = "publicDataBlockCollection"
+		productID  = "org1.example.com/bundle"
+		publicID   = "org1.example.com/public"
+		sharedID   = "org1.example.com/shared"
+		privateID  = "org1.example.com/private"
+	)
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, metadataWithID(publicID), "")
+	mustRegister(t, stub, org1Registrar, metadataWithID(sharedID), collection)
+	mustRegister(t, stub, org1Registrar, metadataWithID(privateID))
+	mustRegister(t, stub, org2Registrar, metadataWithID("org2.example.com/x"))
+
+	// publicState checks that the product on the public ledger lists only the public members
+	publicState := func(t *testing.T, want string) {
+		t.Helper()
+		key, err := stub.CreateCompositeKey(productObjectType, []string{productID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		product := new(DataProduct)
+		if err := product.FromBytes(stub.get("", key)); err != nil {
+			t.Fatal(err)
+		}
+		if got := memberIDs(product); got != want {
+			t.Errorf("public members = %s, want %s", got, want)
+		}
+		for _, private := range []string{sharedID, privateID, collection, "_implicit_org_"} {
+			if strings.Contains(string(stub.get("", key)), private) {
+				t.Errorf("public product discloses %q", private)
+			}
+		}
+	}
+
+	t.Run("create", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			members string
+			err     string
+		}{
+			{name: "missing member", members: `[{"id": "org1.example.com/none", "collection": ""}]`, err: `Dataset "org1.example.com/none" does not exist in collection ""`},
+			{name: "member of another org", members: `[{"id": "org2.example.com/x", "collection": "_implicit_org_Org2MSP"}]`, err: `Collection "_implicit_org_Org2MSP" is not in the collection registry`},
+			{name: "private dataset as public member", members: `[{"id": "` + privateID + `", "collection": ""}]`, err: `Dataset "org1.example.com/private" does not exist in collection ""`},
+			{name: "duplicate member", members: `[{"id": "` + publicID + `", "collection": ""}, {"id": "` + publicID + `", "collection": ""}]`, err: `appears more than once`},
+			{name: "created", members: `[{"id": "` + publicID + `", "collection": ""}, {"id": "` + sharedID + `", "collection": "` + collection + `"}]`},
+			{name: "exists", members: `[]`, err: `Data product "org1.example.com/bundle" already exists`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				product, err := l.CreateDataProduct(as(t, stub, org1Registrar, nil), `{"id": "`+productID+`", "title": "Bundle", "members": `+tt.members+`}`)
+				if tt.err != "" {
+					requireError(t, err, tt.err)
+					return
+				}
+				if err != nil {
+					t.Fatalf("CreateDataProduct: %v", err)
+				}
+				if product.Owner != "Org1MSP" || product.Version != 1 || memberIDs(product) != publicID {
+					t.Errorf("product = %+v", product)
+				}
+			})
+		}
+		publicState(t, publicID)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			id         *testIdentity
+			datasetID  string
+			collection string
+			err        string
+		}{
+			{name: "private member first", id: org1Registrar, datasetID: privateID, collection: implicitPrivateDataCollection("Org1MSP")},
+			{name: "already a member", id: org1Registrar, datasetID: publicID, err: `Dataset "org1.example.com/public" is already a member`},
+			{name: "not visible", id: org1Registrar, datasetID: "org2.example.com/x", collection: implicitPrivateDataCollection("Org2MSP"), err: `is not in the collection registry`},
+			{name: "other owner", id: org2Registrar, datasetID: "org2.example.com/x", collection: implicitPrivateDataCollection("Org2MSP"), err: `Data product "org1.example.com/bundle" is owned by Org "Org1MSP"`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := l.AddToDataProduct(as(t, stub, tt.id, nil), productID, tt.datasetID, tt.collection, 0)
+				if tt.err != "" {
+					requireError(t, err, tt.err)
+					return
+				}
+				if err != nil {
+					t.Fatalf("AddToDataProduct: %v", err)
+				}
+			})
+		}
+		publicState(t, publicID)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		for _, tt := range []struct {
+			id   *testIdentity
+			want string
+		}{
+			{id: org1Reader, want: privateID + "," + publicID + "," + sharedID},
+			{id: org2Reader, want: publicID},
+		} {
+			product, err := l.GetDataProduct(as(t, stub, tt.id, nil), productID)
+			if err != nil {
+				t.Fatalf("GetDataProduct: %v", err)
+			}
+			if got := memberIDs(product); got != tt.want || product.Version != 2 {
+				t.Errorf("members for %s = %s (version %d), want %s", tt.id.mspID, got, product.Version, tt.want)
+			}
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		_, err := l.RemoveFromDataProduct(as(t, stub, org1Registrar, nil), productID, "org1.example.com/none")
+		requireError(t, err, `Dataset "org1.example.com/none" is not a member of data product "org1.example.com/bundle"`)
+
+		for _, id := range []string{sharedID, publicID} {
+			if _, err := l.RemoveFromDataProduct(as(t, stub, org1Registrar, nil), productID, id); err != nil {
+				t.Fatalf("RemoveFromDataProduct: %v", err)
+			}
+		}
+		publicState(t, "")
+	})
+
+	t.Run("list", func(t *testing.T) {
+		if _, err := l.CreateDataProduct(as(t, stub, org2Registrar, nil), `{"id": "org2.example.com/bundle"}`); err != nil {
+			t.Fatalf("CreateDataProduct: %v", err)
+		}
+		for _, tt := range []struct {
+			id    *testIdentity
+			owner string
+			want  []string
+		}{
+			{id: org1Reader, owner: "", want: []string{privateID, ""}},
+			{id: org2Reader, owner: "", want: []string{"", ""}},
+			{id: org2Reader, owner: "Org1MSP", want: []string{""}},
+		} {
+			products, err := l.ListDataProducts(as(t, stub, tt.id, nil), tt.owner)
+			if err != nil {
+				t.Fatalf("ListDataProducts: %v", err)
+			}
+			got := []string{}
+			for _, product := range products {
+				got = append(got, memberIDs(product))
+			}
+			if strings.Join(got, ";") != strings.Join(tt.want, ";") {
+				t.Errorf("members of products listed to %s = %q, want %q", tt.id.mspID, got, tt.want)
+			}
+		}
+	})
+}
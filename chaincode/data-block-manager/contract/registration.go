@@ -0,0 +1,114 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	registrationObjectType = "registration"
+	revisionObjectType     = "revision"
+)
+
+// DatasetRegistration is kept in the owner's implicit collection next to the private metadata
+type DatasetRegistration struct {
+	ID string `json:"id"`
+	// MSP ID of the registering organisation
+	Owner string `json:"owner"`
+	// Client ID of the registering identity
+	Registrar string `json:"registrar"`
+	Revision  int    `json:"revision"`
+	// Collections holding a public copy, where "" stands for the public ledger
+	Collections []string `json:"collections"`
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+	// Retention class and expiry, empty if the dataset never expires
+	RetentionClass string `json:"retentionClass,omitempty"`
+	ExpiresAt      string `json:"expiresAt,omitempty"`
+}
+
+func (r *DatasetRegistration) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode registration to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (r *DatasetRegistration) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, r)
+	if err != nil {
+		return fmt.Errorf("Failed to decode registration.\n%v", err)
+	}
+
+	return nil
+}
+
+func registrationKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return createCompositeKey(ctx, registrationObjectType, id)
+}
+
+// revisions are zero-padded so that a range over the partial key returns them in order
+func revisionKey(ctx contractapi.TransactionContextInterface, id string, revision int) (string, error) {
+	return createCompositeKey(ctx, revisionObjectType, id, fmt.Sprintf("%010d", revision))
+}
+
+// readRegistration returns nil if the dataset is not registered by the organisation
+func readRegistration(ctx contractapi.TransactionContextInterface, mspID string, id string) (*DatasetRegistration, error) {
+	key, err := registrationKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := readFromCollection(ctx, implicitPrivateDataCollection(mspID), key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	reg := new(DatasetRegistration)
+	if err := reg.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+// isRegisteredBy tells from the hash of its registration whether the organisation registered the
+// dataset, which any peer can check without the registration itself being disclosed
+func isRegisteredBy(ctx contractapi.TransactionContextInterface, mspID string, id string) (bool, error) {
+	key, err := registrationKey(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := ctx.GetStub().GetPrivateDataHash(implicitPrivateDataCollection(mspID), key)
+	if err != nil {
+		return false, fmt.Errorf(`Failed to read registration hash of "%s" from Org "%s" : %v`, id, mspID, err)
+	}
+
+	return hash != nil, nil
+}
+
+func writeRegistration(ctx contractapi.TransactionContextInterface, reg *DatasetRegistration) error {
+	key, err := registrationKey(ctx, reg.ID)
+	if err != nil {
+		return err
+	}
+
+	bs, err := reg.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutPrivateData(implicitPrivateDataCollection(reg.Owner), key, bs); err != nil {
+		return fmt.Errorf(`Failed to write registration of "%s" : %v`, reg.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,451 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	retentionObjectType = "retention"
+	// expiry index in the owner's implicit collection, ordered by expiry time
+	expiryObjectType = "expiry"
+)
+
+// retention classes and how long a dataset is kept after registration or renewal,
+// datasets of class "permanent" never expire
+const (
+	retentionShort     = "short"
+	retentionStandard  = "standard"
+	retentionLong      = "long"
+	retentionPermanent = "permanent"
+)
+
+var retentionPeriods = map[string]time.Duration{
+	retentionShort:     30 * 24 * time.Hour,
+	retentionStandard:  365 * 24 * time.Hour,
+	retentionLong:      10 * 365 * 24 * time.Hour,
+	retentionPermanent: 0,
+}
+
+// expiry times are stored in UTC with a fixed layout so that they sort lexicographically
+const expiryLayout = "2006-01-02T15:04:05Z"
+
+// DatasetRetention is written next to every copy of a dataset so that expired copies can be hidden
+type DatasetRetention struct {
+	ID    string `json:"id"`
+	Class string `json:"class"`
+	// Empty if the dataset never expires
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// RetentionInput is read from transient "retention" on registration and renewal
+type RetentionInput struct {
+	Class string `json:"class"`
+	// RFC3339, overrides the period of the class
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+func (r *DatasetRetention) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode retention to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (r *DatasetRetention) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, r)
+	if err != nil {
+		return fmt.Errorf("Failed to decode retention.\n%v", err)
+	}
+
+	return nil
+}
+
+func retentionKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return createCompositeKey(ctx, retentionObjectType, id)
+}
+
+func expiryKey(ctx contractapi.TransactionContextInterface, expiresAt string, id string) (string, error) {
+	return createCompositeKey(ctx, expiryObjectType, expiresAt, id)
+}
+
+// resolveRetention turns the input into a retention class and expiry time, counted from the transaction time
+func resolveRetention(ctx contractapi.TransactionContextInterface, input *RetentionInput) (string, string, error) {
+	class := input.Class
+	if class == "" {
+		class = retentionPermanent
+	}
+	period, ok := retentionPeriods[class]
+	if !ok {
+		return "", "", fmt.Errorf(`Unknown retention class "%s".`, class)
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if input.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, input.ExpiresAt)
+		if err != nil {
+			return "", "", fmt.Errorf(`Expiry "%s" is not an RFC3339 date : %v`, input.ExpiresAt, err)
+		}
+		if !expiresAt.After(now) {
+			return "", "", fmt.Errorf(`Expiry "%s" is not in the future.`, input.ExpiresAt)
+		}
+		return class, expiresAt.UTC().Format(expiryLayout), nil
+	}
+	if period == 0 {
+		return class, "", nil
+	}
+
+	return class, now.Add(period).UTC().Format(expiryLayout), nil
+}
+
+// readRetentionInput decodes the optional transient "retention" and resolves it
+func readRetentionInput(ctx contractapi.TransactionContextInterface, transient map[string][]byte) (string, string, error) {
+	input := new(RetentionInput)
+	if bs, ok := transient["retention"]; ok {
+		if err := json.Unmarshal(bs, input); err != nil {
+			return "", "", fmt.Errorf("Failed to decode retention : %v", err)
+		}
+	}
+
+	return resolveRetention(ctx, input)
+}
+
+// writeRetention marks every copy of the dataset with its retention and indexes its expiry for the owner
+func writeRetention(ctx contractapi.TransactionContextInterface, reg *DatasetRegistration) error {
+	retention := &DatasetRetention{ID: reg.ID, Class: reg.RetentionClass, ExpiresAt: reg.ExpiresAt}
+	bs, err := retention.ToBytes()
+	if err != nil {
+		return err
+	}
+	key, err := retentionKey(ctx, reg.ID)
+	if err != nil {
+		return err
+	}
+	implicitCollection := implicitPrivateDataCollection(reg.Owner)
+	targets := append(append([]string{}, reg.Collections...), implicitCollection)
+	for _, target := range targets {
+		if target == "" {
+			err = ctx.GetStub().PutState(key, bs)
+		} else {
+			err = ctx.GetStub().PutPrivateData(target, key, bs)
+		}
+		if err != nil {
+			return fmt.Errorf(`Failed to write retention of "%s" to collection "%s" : %v`, reg.ID, target, err)
+		}
+	}
+
+	if reg.ExpiresAt == "" {
+		return nil
+	}
+	idxKey, err := expiryKey(ctx, reg.ExpiresAt, reg.ID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutPrivateData(implicitCollection, idxKey, indexValue); err != nil {
+		return fmt.Errorf(`Failed to index expiry of "%s" : %v`, reg.ID, err)
+	}
+
+	return nil
+}
+
+// deleteRetention removes the retention marks and the expiry index entry of the dataset
+func deleteRetention(ctx contractapi.TransactionContextInterface, reg *DatasetRegistration) error {
+	key, err := retentionKey(ctx, reg.ID)
+	if err != nil {
+		return err
+	}
+	implicitCollection := implicitPrivateDataCollection(reg.Owner)
+	targets := append(append([]string{}, reg.Collections...), implicitCollection)
+	if err := deleteFromTargets(ctx, targets, key); err != nil {
+		return err
+	}
+
+	if reg.ExpiresAt == "" {
+		return nil
+	}
+	idxKey, err := expiryKey(ctx, reg.ExpiresAt, reg.ID)
+	if err != nil {
+		return err
+	}
+	return deleteFromCollection(ctx, implicitCollection, idxKey)
+}
+
+// readRetention returns nil if the copy in the target carries no retention
+func readRetention(ctx contractapi.TransactionContextInterface, target string, id string) (*DatasetRetention, error) {
+	key, err := retentionKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromTarget(ctx, target, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	retention := new(DatasetRetention)
+	if err := retention.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+// isExpired reports whether the copy of the dataset in the target has expired at the transaction time
+func isExpired(ctx contractapi.TransactionContextInterface, target string, id string) (bool, string, error) {
+	retention, err := readRetention(ctx, target, id)
+	if err != nil {
+		return false, "", err
+	}
+	if retention == nil || retention.ExpiresAt == "" {
+		return false, "", nil
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	return now.UTC().Format(expiryLayout) >= retention.ExpiresAt, retention.ExpiresAt, nil
+}
+
+func requireNotExpired(ctx contractapi.TransactionContextInterface, target string, id string) error {
+	expired, expiresAt, err := isExpired(ctx, target, id)
+	if err != nil {
+		return err
+	}
+	if expired {
+		return notFoundError(`Dataset "%s" expired at %s.`, id, expiresAt)
+	}
+
+	return nil
+}
+
+// readExpiring returns the datasets of the organisation expiring before the given time, soonest first
+func readExpiring(ctx contractapi.TransactionContextInterface, mspID string, before string, max int) ([]*DatasetRetention, error) {
+	it, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(implicitPrivateDataCollection(mspID), expiryObjectType, []string{})
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to read expiry index of Org "%s" : %v`, mspID, err)
+	}
+	defer it.Close()
+
+	result := []*DatasetRetention{}
+	for it.HasNext() && len(result) < max {
+		item, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(item.Key)
+		if err != nil {
+			return nil, fmt.Errorf(`Failed to split composite key "%s" : %v`, item.Key, err)
+		}
+		if attributes[0] >= before {
+			break
+		}
+		result = append(result, &DatasetRetention{ID: attributes[1], ExpiresAt: attributes[0]})
+	}
+
+	return result, nil
+}
+
+// RetentionStatus reports the retention of a dataset to its owner
+type RetentionStatus struct {
+	ID        string `json:"id"`
+	Class     string `json:"class"`
+	ExpiresAt string `json:"expiresAt"`
+	Expired   bool   `json:"expired"`
+}
+
+// QueryExpiring reports the datasets of the client's organisation that have expired or expire within the
+// given number of days, so that maintainers can renew them
+func (l *DatasetMetadataLedger) QueryExpiring(ctx contractapi.TransactionContextInterface, days int, max int) ([]*RetentionStatus, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opQueryPrivate); err != nil {
+		return nil, err
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before := now.Add(time.Duration(days) * 24 * time.Hour).UTC().Format(expiryLayout)
+	expiring, err := readExpiring(ctx, mspID, before, max)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*RetentionStatus{}
+	for _, e := range expiring {
+		reg, err := readRegistration(ctx, mspID, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		if reg == nil {
+			continue
+		}
+		result = append(result, &RetentionStatus{
+			ID:        e.ID,
+			Class:     reg.RetentionClass,
+			ExpiresAt: e.ExpiresAt,
+			Expired:   now.UTC().Format(expiryLayout) >= e.ExpiresAt,
+		})
+	}
+
+	return result, nil
+}
+
+// RenewRetention sets a new retention for a dataset of the client's organisation, counted from now,
+// with the class and optional expiry given in transient "retention"
+func (l *DatasetMetadataLedger) RenewRetention(ctx contractapi.TransactionContextInterface, key string) (*RetentionStatus, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opUpdate); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, key)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, key, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return nil, err
+	}
+
+	transient, err := getTxTransient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	class, expiresAt, err := readRetentionInput(ctx, transient)
+	if err != nil {
+		return nil, err
+	}
+
+	if reg.ExpiresAt != "" {
+		idxKey, err := expiryKey(ctx, reg.ExpiresAt, reg.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := deleteFromCollection(ctx, implicitPrivateDataCollection(mspID), idxKey); err != nil {
+			return nil, err
+		}
+	}
+	reg.RetentionClass = class
+	reg.ExpiresAt = expiresAt
+	if err := writeRetention(ctx, reg); err != nil {
+		return nil, err
+	}
+	if err := writeRegistration(ctx, reg); err != nil {
+		return nil, err
+	}
+
+	return &RetentionStatus{ID: key, Class: class, ExpiresAt: expiresAt}, nil
+}
+
+// PurgeExpired removes up to max expired datasets of the client's organisation from every collection
+// they were published to, and purges them with their history from the implicit collection
+func (l *DatasetMetadataLedger) PurgeExpired(ctx contractapi.TransactionContextInterface, max int) ([]string, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opPurgeExpired); err != nil {
+		return nil, err
+	}
+
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expired, err := readExpiring(ctx, mspID, now.UTC().Format(expiryLayout), max)
+	if err != nil {
+		return nil, err
+	}
+
+	implicitCollection := implicitPrivateDataCollection(mspID)
+	purged := []string{}
+	collections := []string{}
+	for _, e := range expired {
+		idxKey, err := expiryKey(ctx, e.ExpiresAt, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		reg, err := readRegistration(ctx, mspID, e.ID)
+		if err != nil {
+			return nil, err
+		}
+		// stale index entry of a renewed or retired dataset
+		if reg == nil || reg.ExpiresAt != e.ExpiresAt {
+			if err := ctx.GetStub().PurgePrivateData(implicitCollection, idxKey); err != nil {
+				return nil, fmt.Errorf(`Failed to purge "%s" from collection "%s" : %v`, idxKey, implicitCollection, err)
+			}
+			continue
+		}
+
+		if err := withdrawProjections(ctx, reg.Collections, reg.ID); err != nil {
+			return nil, err
+		}
+		retKey, err := retentionKey(ctx, reg.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := deleteFromTargets(ctx, reg.Collections, retKey); err != nil {
+			return nil, err
+		}
+
+		regKey, err := registrationKey(ctx, reg.ID)
+		if err != nil {
+			return nil, err
+		}
+		keys := []string{reg.ID, regKey, retKey, idxKey}
+		for revision := 1; revision < reg.Revision; revision++ {
+			revKey, err := revisionKey(ctx, reg.ID, revision)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, revKey)
+		}
+		for _, key := range keys {
+			if err := ctx.GetStub().PurgePrivateData(implicitCollection, key); err != nil {
+				return nil, fmt.Errorf(`Failed to purge "%s" from collection "%s" : %v`, key, implicitCollection, err)
+			}
+		}
+
+		purged = append(purged, reg.ID)
+		for _, target := range reg.Collections {
+			if !containsString(collections, target) {
+				collections = append(collections, target)
+			}
+		}
+	}
+
+	if len(purged) > 0 {
+		if err := emitDatasetEvent(ctx, eventDatasetsExpired, &DatasetEvent{
+			IDs:         purged,
+			Owner:       mspID,
+			Collections: collections,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return purged, nil
+}
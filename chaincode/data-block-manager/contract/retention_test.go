@@ -0,0 +1,120 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// registerWithRetention registers the metadata in the collections with the retention input
+func registerWithRetention(t *testing.T, stub *testStub, metadata string, retention string, collections ...string) error {
+	t.Helper()
+	transient := registerTransient(t, metadata, collections...)
+	transient["retention"] = []byte(retention)
+	return new(DatasetMetadataLedger).Register(as(t, stub, org1Registrar, transient))
+}
+
+func TestRetentionInput(t *testing.T) {
+	stub := newTestStub()
+	tests := []struct {
+		name      string
+		retention string
+		err       string
+	}{
+		{name: "short", retention: `{"class":"short"}`},
+		{name: "permanent", retention: `{}`},
+		{name: "explicit expiry", retention: `{"class":"standard","expiresAt":"2030-01-01T00:00:00+02:00"}`},
+		{name: "unknown class", retention: `{"class":"forever"}`, err: `Unknown retention class "forever"`},
+		{name: "expiry not RFC3339", retention: `{"expiresAt":"2030-01-01"}`, err: `Expiry "2030-01-01" is not an RFC3339 date`},
+		{name: "expiry in the past", retention: `{"expiresAt":"2020-01-01T00:00:00Z"}`, err: `Expiry "2020-01-01T00:00:00Z" is not in the future`},
+		{name: "not JSON", retention: `short`, err: "Failed to decode retention"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := metadataWithID(exampleID + string(rune('a'+i)))
+			err := registerWithRetention(t, stub, id, tt.retention, "")
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+		})
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	const (
+		collection = "publicDataBlockCollection"
+		shortID    = "org1.example.com/short"
+		keptID     = "org1.example.com/kept"
+	)
+	if err := registerWithRetention(t, stub, metadataWithID(shortID), `{"class":"short"}`, "", collection); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := registerWithRetention(t, stub, metadataWithID(keptID), `{"class":"standard"}`, "", collection); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	implicitCollection := implicitPrivateDataCollection("Org1MSP")
+
+	t.Run("nothing expired yet", func(t *testing.T) {
+		purged, err := l.PurgeExpired(as(t, stub, org1Registrar, nil), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(purged) != 0 {
+			t.Errorf("purged %v", purged)
+		}
+	})
+
+	stub.txTime = stub.txTime.Add(31 * 24 * time.Hour)
+
+	t.Run("expired hidden", func(t *testing.T) {
+		for _, target := range []string{"", collection} {
+			_, err := l.Query(as(t, stub, org2Reader, nil), target, shortID)
+			requireError(t, err, `Dataset "org1.example.com/short" expired at`)
+		}
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		_, err := l.PurgeExpired(as(t, stub, org1Reader, nil), 10)
+		requireError(t, err, `is denied "PurgeExpired"`)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		purged, err := l.PurgeExpired(as(t, stub, org1Registrar, nil), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(purged) != 1 || purged[0] != shortID {
+			t.Fatalf("purged %v, want [%s]", purged, shortID)
+		}
+
+		for _, target := range []string{"", collection, implicitCollection} {
+			if stub.get(target, shortID) != nil {
+				t.Errorf("copy of %s left in %q", shortID, target)
+			}
+			if stub.get(target, keptID) == nil {
+				t.Errorf("copy of %s missing from %q", keptID, target)
+			}
+		}
+		for key := range stub.state[implicitCollection] {
+			if strings.Contains(key, shortID) {
+				t.Errorf("key %q of %s left in the implicit collection", key, shortID)
+			}
+		}
+	})
+
+	t.Run("purged once", func(t *testing.T) {
+		purged, err := l.PurgeExpired(as(t, stub, org1Registrar, nil), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(purged) != 0 {
+			t.Errorf("purged %v again", purged)
+		}
+	})
+}
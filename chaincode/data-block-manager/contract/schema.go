@@ -0,0 +1,118 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const schemaObjectType = "schema"
+
+func schemaKey(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	return createCompositeKey(ctx, schemaObjectType, mspID)
+}
+
+// remote references would make endorsement depend on the network, so only local ones are allowed
+func requireLocalReferences(node interface{}) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if ref, ok := child.(string); ok && name == "$ref" && !strings.HasPrefix(ref, "#") {
+				return fmt.Errorf(`Schema reference "%s" is not local.`, ref)
+			}
+			if err := requireLocalReferences(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := requireLocalReferences(child); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readSchema returns nil if the organisation has not stored a schema
+func readSchema(ctx contractapi.TransactionContextInterface, mspID string) ([]byte, error) {
+	key, err := schemaKey(ctx, mspID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readFromPublic(ctx, key)
+}
+
+// validateAgainstSchema checks raw metadata against the JSON Schema stored by the organisation, on top of Validate()
+func validateAgainstSchema(ctx contractapi.TransactionContextInterface, mspID string, mdAsBytes []byte) error {
+	schema, err := readSchema(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(mdAsBytes))
+	if err != nil {
+		return fmt.Errorf(`Failed to validate metadata against schema of Org "%s" : %v`, mspID, err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	errs := ValidationErrors{}
+	for _, e := range result.Errors() {
+		errs.add(e.Field(), e.Description())
+	}
+
+	return errs
+}
+
+// SetSchema stores a JSON Schema that metadata registered by the client's organisation must satisfy
+func (l *DatasetMetadataLedger) SetSchema(ctx contractapi.TransactionContextInterface, schema string) error {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return err
+	}
+
+	if err := requireCertification(ctx, opSetSchema); err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		return fmt.Errorf("Failed to decode schema : %v", err)
+	}
+	if err := requireLocalReferences(doc); err != nil {
+		return err
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(doc)); err != nil {
+		return fmt.Errorf("Invalid schema : %v", err)
+	}
+
+	key, err := schemaKey(ctx, mspID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, []byte(schema)); err != nil {
+		return fmt.Errorf(`Failed to write schema of Org "%s" : %v`, mspID, err)
+	}
+
+	return nil
+}
+
+// GetSchema returns the JSON Schema stored by an organisation, or "" if there is none
+func (l *DatasetMetadataLedger) GetSchema(ctx contractapi.TransactionContextInterface, mspID string) (string, error) {
+	schema, err := readSchema(ctx, mspID)
+	if err != nil {
+		return "", err
+	}
+
+	return string(schema), nil
+}
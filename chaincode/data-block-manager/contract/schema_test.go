@@ -0,0 +1,76 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestSetSchema(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+
+	tests := []struct {
+		name   string
+		id     *testIdentity
+		schema string
+		err    string
+	}{
+		{name: "not JSON", id: org1Admin, schema: `{`, err: "Failed to decode schema"},
+		{name: "remote reference", id: org1Admin, schema: `{"properties": {"id": {"$ref": "https://example.com/id.json"}}}`, err: `Schema reference "https://example.com/id.json" is not local`},
+		{name: "invalid schema", id: org1Admin, schema: `{"type": "thing"}`, err: "Invalid schema"},
+		{name: "registrar", id: org1Registrar, schema: `{"type": "object"}`, err: `is denied "SetSchema"`},
+		{name: "local reference", id: org1Admin, schema: `{"definitions": {"s": {"type": "string"}}, "properties": {"title": {"$ref": "#/definitions/s"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.SetSchema(as(t, stub, tt.id, nil), tt.schema)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetSchema: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegisterValidatesAgainstSchema(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	schema := `{"type": "object", "required": ["description"], "properties": {"tags": {"type": "array", "minItems": 3}}}`
+	if err := l.SetSchema(as(t, stub, org1Admin, nil), schema); err != nil {
+		t.Fatalf("SetSchema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		id       *testIdentity
+		metadata string
+		errs     []string
+	}{
+		{name: "violations", id: org1Registrar, metadata: metadataWithID("org1.example.com/data001"), errs: []string{"description is required", "tags: Array must have at least 3 items"}},
+		{name: "satisfied", id: org1Registrar, metadata: `{"id": "org1.example.com/data002", "date": "2022-01-01T09:00:00Z", "description": "d", "tags": ["a", "b", "c"]}`},
+		{name: "schema of other organisation", id: org2Registrar, metadata: metadataWithID("org2.example.com/data001")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.Register(as(t, stub, tt.id, registerTransient(t, tt.metadata)))
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Fatalf("Register: %v", err)
+				}
+				return
+			}
+			for _, want := range tt.errs {
+				requireError(t, err, want)
+			}
+		})
+	}
+
+	t.Run("stored", func(t *testing.T) {
+		got, err := l.GetSchema(as(t, stub, org2Reader, nil), "Org1MSP")
+		if err != nil || got != schema {
+			t.Errorf("GetSchema = %q, %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// PaginatedQueryResult structure used for returning paginated query results and metadata
+type PaginatedQueryResult struct {
+	Records             []*DatasetMetadataPublic `json:"records"`
+	FetchedRecordsCount int32                    `json:"fetchedRecordsCount"`
+	Bookmark            string                   `json:"bookmark"`
+}
+
+// buildSelectorQuery wraps a CouchDB selector, e.g. '{"organisation":"org1.example.com"}', into a query string
+func buildSelectorQuery(selector string) (string, error) {
+	var sel map[string]interface{}
+	if err := json.Unmarshal([]byte(selector), &sel); err != nil {
+		return "", fmt.Errorf("Failed to decode selector : %v", err)
+	}
+
+	bs, err := json.Marshal(map[string]interface{}{"selector": sel})
+	if err != nil {
+		return "", fmt.Errorf("Failed to encode query : %v", err)
+	}
+
+	return string(bs), nil
+}
+
+// composite keys hold registrations, tombstones, policies and indexes rather than metadata
+func isCompositeKey(key string) bool {
+	return strings.HasPrefix(key, "\x00")
+}
+
+// toMetadataRecords skips auxiliary records and expired datasets
+func toMetadataRecords(ctx contractapi.TransactionContextInterface, target string, it shim.StateQueryIteratorInterface, max int) ([]*DatasetMetadataPublic, int32, error) {
+	records := []*DatasetMetadataPublic{}
+	var fetched int32
+
+	for it.HasNext() && len(records) < max {
+		item, err := it.Next()
+		if err != nil {
+			return nil, 0, err
+		}
+		fetched++
+		if isCompositeKey(item.Key) {
+			continue
+		}
+		expired, _, err := isExpired(ctx, target, item.Key)
+		if err != nil {
+			return nil, 0, err
+		}
+		if expired {
+			continue
+		}
+		md := new(DatasetMetadataPublic)
+		if err := md.FromBytes(item.Value); err != nil {
+			return nil, 0, err
+		}
+		records = append(records, md)
+	}
+
+	return records, fetched, nil
+}
+
+func searchFromPublic(ctx contractapi.TransactionContextInterface, query string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	it, responseMetadata, err := ctx.GetStub().GetQueryResultWithPagination(query, int32(pageSize), bookmark)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to query the public ledger with "%s" : %v`, query, err)
+	}
+	defer it.Close()
+
+	records, _, err := toMetadataRecords(ctx, "", it, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// CouchDB hands out a bookmark even after the last page
+	next := responseMetadata.Bookmark
+	if responseMetadata.FetchedRecordsCount < int32(pageSize) {
+		next = ""
+	}
+
+	return &PaginatedQueryResult{
+		Records:             records,
+		FetchedRecordsCount: responseMetadata.FetchedRecordsCount,
+		Bookmark:            next,
+	}, nil
+}
+
+// private data queries have no native pagination, so the bookmark is the number of records already fetched,
+// and is empty once the results are exhausted
+func searchFromCollection(ctx contractapi.TransactionContextInterface, collection string, query string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	offset := 0
+	if bookmark != "" {
+		var err error
+		if offset, err = strconv.Atoi(bookmark); err != nil || offset < 0 {
+			return nil, fmt.Errorf(`Invalid bookmark "%s" for collection "%s".`, bookmark, collection)
+		}
+	}
+
+	it, err := ctx.GetStub().GetPrivateDataQueryResult(collection, query)
+	if err != nil {
+		return nil, fmt.Errorf(`Failed to query collection "%s" with "%s" : %v`, collection, query, err)
+	}
+	defer it.Close()
+
+	for skipped := 0; skipped < offset && it.HasNext(); skipped++ {
+		if _, err := it.Next(); err != nil {
+			return nil, err
+		}
+	}
+
+	records, fetched, err := toMetadataRecords(ctx, collection, it, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	next := ""
+	if it.HasNext() {
+		next = strconv.Itoa(offset + int(fetched))
+	}
+
+	return &PaginatedQueryResult{
+		Records:             records,
+		FetchedRecordsCount: fetched,
+		Bookmark:            next,
+	}, nil
+}
+
+// Search runs a CouchDB selector against the public ledger or a named collection, one page at a time.
+// Pass the returned bookmark to fetch the next page.
+func (l *DatasetMetadataLedger) Search(ctx contractapi.TransactionContextInterface, collection string, selector string, pageSize int, bookmark string) (*PaginatedQueryResult, error) {
+	if err := requireCertification(ctx, opSearch); err != nil {
+		return nil, err
+	}
+
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("Page size must be positive, got %d.", pageSize)
+	}
+
+	query, err := buildSelectorQuery(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	if collection != "" {
+		return searchFromCollection(ctx, collection, query, pageSize, bookmark)
+	}
+	return searchFromPublic(ctx, query, pageSize, bookmark)
+}
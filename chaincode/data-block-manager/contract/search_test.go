@@ -0,0 +1,76 @@
+package contract
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	const (
+		collection = "publicDataBlockCollection"
+		selector   = `{"organisation":"org1.example.com"}`
+	)
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	for i := 1; i <= 3; i++ {
+		mustRegister(t, stub, org1Registrar, metadataWithID(fmt.Sprintf("org1.example.com/search%d", i)), "", collection)
+	}
+	other := strings.Replace(metadataWithID("org1.example.com/other"), `"organisation": "org1.example.com"`, `"organisation": "org9.example.com"`, 1)
+	mustRegister(t, stub, org1Registrar, other, "", collection)
+
+	for _, target := range []string{"", collection} {
+		t.Run(fmt.Sprintf("pages of %q", target), func(t *testing.T) {
+			ids := []string{}
+			bookmark := ""
+			for page := 0; page < 3; page++ {
+				result, err := l.Search(as(t, stub, org2Reader, nil), target, selector, 2, bookmark)
+				if err != nil {
+					t.Fatalf("Search: %v", err)
+				}
+				for _, md := range result.Records {
+					ids = append(ids, md.ID)
+				}
+				bookmark = result.Bookmark
+				if bookmark == "" {
+					break
+				}
+			}
+			if bookmark != "" {
+				t.Fatalf("bookmark %q left after the last page", bookmark)
+			}
+			want := "org1.example.com/search1,org1.example.com/search2,org1.example.com/search3"
+			if got := strings.Join(ids, ","); got != want {
+				t.Errorf("ids = %s, want %s", got, want)
+			}
+		})
+
+		t.Run(fmt.Sprintf("single page of %q", target), func(t *testing.T) {
+			result, err := l.Search(as(t, stub, org2Reader, nil), target, selector, 10, "")
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(result.Records) != 3 || result.Bookmark != "" {
+				t.Errorf("records = %d, bookmark = %q", len(result.Records), result.Bookmark)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		name     string
+		selector string
+		pageSize int
+		bookmark string
+		err      string
+	}{
+		{name: "negative bookmark", selector: selector, pageSize: 2, bookmark: "-1", err: `Invalid bookmark "-1" for collection "publicDataBlockCollection"`},
+		{name: "text bookmark", selector: selector, pageSize: 2, bookmark: "next", err: `Invalid bookmark "next" for collection "publicDataBlockCollection"`},
+		{name: "page size", selector: selector, pageSize: 0, err: "Page size must be positive, got 0"},
+		{name: "selector", selector: "organisation", pageSize: 2, err: "Failed to decode selector"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := l.Search(as(t, stub, org2Reader, nil), collection, tt.selector, tt.pageSize, tt.bookmark)
+			requireError(t, err, tt.err)
+		})
+	}
+}
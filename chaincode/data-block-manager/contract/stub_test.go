@@ -0,0 +1,371 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestMain loads the collection configuration deployed with the chaincode
+func TestMain(m *testing.M) {
+	bs, err := os.ReadFile("../collection_config.json")
+	if err == nil {
+		err = LoadCollectionConfig(bs)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// testStub is an in-memory ledger covering the stub calls of the contract. The public ledger is
+// kept under collection "", and a write of a transaction is visible to the reads that follow it.
+type testStub struct {
+	shim.ChaincodeStubInterface
+
+	state     map[string]map[string][]byte
+	transient map[string][]byte
+	events    map[string][]byte
+	txID      string
+	txTime    time.Time
+	txCount   int
+}
+
+func newTestStub() *testStub {
+	return &testStub{
+		state:  map[string]map[string][]byte{},
+		events: map[string][]byte{},
+		txTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// startTx starts a new transaction with the transient map, one second after the previous one
+func (s *testStub) startTx(transient map[string][]byte) {
+	s.txCount++
+	s.txID = fmt.Sprintf("tx%d", s.txCount)
+	s.txTime = s.txTime.Add(time.Second)
+	s.transient = transient
+	s.events = map[string][]byte{}
+}
+
+func (s *testStub) get(collection string, key string) []byte {
+	return s.state[collection][key]
+}
+
+func (s *testStub) put(collection string, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	if s.state[collection] == nil {
+		s.state[collection] = map[string][]byte{}
+	}
+	s.state[collection][key] = value
+	return nil
+}
+
+// rangeOf returns the entries of the collection in [start, end) in key order, an empty end leaves
+// the range open
+func (s *testStub) rangeOf(collection string, start string, end string) []*queryresult.KV {
+	keys := []string{}
+	for key := range s.state[collection] {
+		if key >= start && (end == "" || key < end) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	items := make([]*queryresult.KV, len(keys))
+	for i, key := range keys {
+		items[i] = &queryresult.KV{Key: key, Value: s.state[collection][key]}
+	}
+	return items
+}
+
+// simpleRange leaves out the composite keys, as the peer does for range queries
+func (s *testStub) simpleRange(collection string, start string, end string) []*queryresult.KV {
+	items := []*queryresult.KV{}
+	for _, item := range s.rangeOf(collection, start, end) {
+		if !isCompositeKey(item.Key) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (s *testStub) partial(collection string, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := s.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &testIterator{items: s.rangeOf(collection, prefix, prefix+string(utf8.MaxRune))}, nil
+}
+
+func (s *testStub) GetTxID() string {
+	return s.txID
+}
+
+func (s *testStub) GetTxTimestamp() (*timestamppb.Timestamp, error) {
+	return timestamppb.New(s.txTime), nil
+}
+
+func (s *testStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *testStub) SetEvent(name string, payload []byte) error {
+	s.events[name] = payload
+	return nil
+}
+
+func (s *testStub) GetState(key string) ([]byte, error) {
+	return s.get("", key), nil
+}
+
+func (s *testStub) PutState(key string, value []byte) error {
+	return s.put("", key, value)
+}
+
+func (s *testStub) DelState(key string) error {
+	delete(s.state[""], key)
+	return nil
+}
+
+func (s *testStub) GetPrivateData(collection string, key string) ([]byte, error) {
+	return s.get(collection, key), nil
+}
+
+func (s *testStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	value := s.get(collection, key)
+	if value == nil {
+		return nil, nil
+	}
+	sum := sha256.Sum256(value)
+	return sum[:], nil
+}
+
+func (s *testStub) PutPrivateData(collection string, key string, value []byte) error {
+	return s.put(collection, key, value)
+}
+
+func (s *testStub) DelPrivateData(collection string, key string) error {
+	delete(s.state[collection], key)
+	return nil
+}
+
+func (s *testStub) PurgePrivateData(collection string, key string) error {
+	return s.DelPrivateData(collection, key)
+}
+
+func (s *testStub) GetStateByRange(start string, end string) (shim.StateQueryIteratorInterface, error) {
+	return &testIterator{items: s.simpleRange("", start, end)}, nil
+}
+
+// GetStateByRangeWithPagination returns the key to resume from as bookmark, "" once the range is exhausted
+func (s *testStub) GetStateByRangeWithPagination(start string, end string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" {
+		start = bookmark
+	}
+	items := s.simpleRange("", start, end)
+	next := ""
+	if len(items) > int(pageSize) {
+		next = items[pageSize].Key
+		items = items[:pageSize]
+	}
+	return &testIterator{items: items}, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(items)), Bookmark: next}, nil
+}
+
+func (s *testStub) GetPrivateDataByRange(collection string, start string, end string) (shim.StateQueryIteratorInterface, error) {
+	return &testIterator{items: s.simpleRange(collection, start, end)}, nil
+}
+
+func (s *testStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	return s.partial("", objectType, attributes)
+}
+
+func (s *testStub) GetPrivateDataByPartialCompositeKey(collection string, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	return s.partial(collection, objectType, attributes)
+}
+
+// selectorRange returns the JSON entries of the collection from start on whose top-level fields equal
+// those of the selector in the query, which covers the selectors built by the contract
+func (s *testStub) selectorRange(collection string, query string, start string) ([]*queryresult.KV, error) {
+	var q struct {
+		Selector map[string]interface{} `json:"selector"`
+	}
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return nil, fmt.Errorf("invalid query %s: %v", query, err)
+	}
+
+	items := []*queryresult.KV{}
+	for _, item := range s.rangeOf(collection, start, "") {
+		var doc map[string]interface{}
+		if json.Unmarshal(item.Value, &doc) != nil {
+			continue
+		}
+		matched := true
+		for field, want := range q.Selector {
+			if fmt.Sprint(doc[field]) != fmt.Sprint(want) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// GetQueryResultWithPagination returns the key to resume from as bookmark, "" once the results are exhausted
+func (s *testStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	items, err := s.selectorRange("", query, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	next := ""
+	if len(items) > int(pageSize) {
+		next = items[pageSize].Key
+		items = items[:pageSize]
+	}
+	return &testIterator{items: items}, &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(items)), Bookmark: next}, nil
+}
+
+func (s *testStub) GetPrivateDataQueryResult(collection string, query string) (shim.StateQueryIteratorInterface, error) {
+	items, err := s.selectorRange(collection, query, "")
+	if err != nil {
+		return nil, err
+	}
+	return &testIterator{items: items}, nil
+}
+
+func (s *testStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *testStub) SplitCompositeKey(key string) (string, []string, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "\x00"), "\x00"), "\x00")
+	return parts[0], parts[1:], nil
+}
+
+type testIterator struct {
+	items []*queryresult.KV
+	i     int
+}
+
+func (it *testIterator) HasNext() bool {
+	return it.i < len(it.items)
+}
+
+func (it *testIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("iterator exhausted")
+	}
+	it.i++
+	return it.items[it.i-1], nil
+}
+
+func (it *testIterator) Close() error {
+	return nil
+}
+
+// testIdentity is a client of an organisation with the given OU roles and X.509 attributes
+type testIdentity struct {
+	mspID      string
+	name       string
+	ouRoles    []string
+	attributes map[string]string
+}
+
+func (id *testIdentity) GetID() (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte("x509::CN=" + id.name + "::CN=ca." + id.mspID)), nil
+}
+
+func (id *testIdentity) GetMSPID() (string, error) {
+	return id.mspID, nil
+}
+
+func (id *testIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, ok := id.attributes[name]
+	return value, ok, nil
+}
+
+func (id *testIdentity) AssertAttributeValue(name string, value string) error {
+	if id.attributes[name] != value {
+		return fmt.Errorf("attribute %s is not %s", name, value)
+	}
+	return nil
+}
+
+func (id *testIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: id.name, OrganizationalUnit: id.ouRoles}}, nil
+}
+
+var (
+	org1Registrar = &testIdentity{mspID: "Org1MSP", name: "registrar1", ouRoles: []string{"client"}, attributes: map[string]string{"dataset.registrar": "true"}}
+	org1Admin     = &testIdentity{mspID: "Org1MSP", name: "admin1", ouRoles: []string{"admin"}}
+	org1Reader    = &testIdentity{mspID: "Org1MSP", name: "reader1", ouRoles: []string{"client"}, attributes: map[string]string{"dataset.reader": "1"}}
+	org2Registrar = &testIdentity{mspID: "Org2MSP", name: "registrar2", ouRoles: []string{"client"}, attributes: map[string]string{"dataset.registrar": "true"}}
+	org2Admin     = &testIdentity{mspID: "Org2MSP", name: "admin2", ouRoles: []string{"admin"}}
+	org2Reader    = &testIdentity{mspID: "Org2MSP", name: "reader2", ouRoles: []string{"client"}, attributes: map[string]string{"dataset.reader": "1"}}
+)
+
+// as starts a transaction of the client on a peer of its own organisation
+func as(t *testing.T, stub *testStub, id *testIdentity, transient map[string][]byte) contractapi.TransactionContextInterface {
+	t.Helper()
+	t.Setenv("CORE_PEER_LOCALMSPID", id.mspID)
+	stub.startTx(transient)
+
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(id)
+	return ctx
+}
+
+// registerTransient is the transient map of Register
+func registerTransient(t *testing.T, metadata string, collections ...string) map[string][]byte {
+	t.Helper()
+	if collections == nil {
+		collections = []string{}
+	}
+	bs, err := json.Marshal(collections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string][]byte{"metadata": []byte(metadata), "collections": bs}
+}
+
+// mustRegister registers the metadata for the client in the collections, "" standing for the public ledger
+func mustRegister(t *testing.T, stub *testStub, id *testIdentity, metadata string, collections ...string) {
+	t.Helper()
+	l := new(DatasetMetadataLedger)
+	if err := l.Register(as(t, stub, id, registerTransient(t, metadata, collections...))); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+}
+
+// requireError fails unless err is not nil and its message contains want
+func requireError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected an error containing %q, got none", want)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected an error containing %q, got %q", want, err.Error())
+	}
+}
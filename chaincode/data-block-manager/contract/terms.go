@@ -0,0 +1,352 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	termsObjectType           = "terms"
+	termsAcceptanceObjectType = "terms~acceptance"
+)
+
+// TermsContent are the machine-readable usage terms a consumer accepts
+type TermsContent struct {
+	// Purposes the dataset may be used for, any purpose if empty
+	AllowedPurposes []string `json:"allowedPurposes,omitempty"`
+	// Attribution required when using the dataset
+	Attribution      string `json:"attribution,omitempty"`
+	NoRedistribution bool   `json:"noRedistribution"`
+	// RFC3339, acceptances lapse once the terms expire
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// DatasetTerms are kept on the public ledger so that consumers can read them before accepting
+type DatasetTerms struct {
+	DatasetID string       `json:"datasetID"`
+	Owner     string       `json:"owner"`
+	Terms     TermsContent `json:"terms"`
+	Version   int          `json:"version"`
+	// Hex SHA-256 of the JSON encoding of TermsContent
+	Hash      string `json:"hash"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// TermsAcceptance binds a consumer identity to the hash of the terms it accepted
+type TermsAcceptance struct {
+	DatasetID string `json:"datasetID"`
+	// MSP ID of the owning organisation
+	Owner string `json:"owner"`
+	// MSP ID and client ID of the consumer
+	Consumer       string `json:"consumer"`
+	ConsumerClient string `json:"consumerClient"`
+	TermsHash      string `json:"termsHash"`
+	TermsVersion   int    `json:"termsVersion"`
+	Purpose        string `json:"purpose"`
+	AcceptedAt     string `json:"acceptedAt"`
+}
+
+func (c *TermsContent) hash() (string, error) {
+	bs, err := json.Marshal(*c)
+	if err != nil {
+		return "", fmt.Errorf("Failed to encode terms to bytes.\n%v", err)
+	}
+	sum := sha256.Sum256(bs)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (t *DatasetTerms) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*t)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode terms to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (t *DatasetTerms) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, t)
+	if err != nil {
+		return fmt.Errorf("Failed to decode terms.\n%v", err)
+	}
+
+	return nil
+}
+
+// expired reports whether the terms have lapsed at the given time
+func (t *DatasetTerms) expired(now time.Time) bool {
+	if t.Terms.ExpiresAt == "" {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, t.Terms.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+func (a *TermsAcceptance) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*a)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode terms acceptance to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (a *TermsAcceptance) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, a)
+	if err != nil {
+		return fmt.Errorf("Failed to decode terms acceptance.\n%v", err)
+	}
+
+	return nil
+}
+
+// terms are keyed by owner, as organisations may register datasets under the same ID
+func termsKey(ctx contractapi.TransactionContextInterface, owner string, datasetID string) (string, error) {
+	return createCompositeKey(ctx, termsObjectType, owner, datasetID)
+}
+
+// acceptances are kept per client identity, the one filing an access request must have accepted the terms
+func termsAcceptanceKey(ctx contractapi.TransactionContextInterface, owner string, datasetID string, mspID string, clientID string) (string, error) {
+	return createCompositeKey(ctx, termsAcceptanceObjectType, owner, datasetID, mspID, clientID)
+}
+
+// readTerms returns nil if the owner has not attached terms to the dataset
+func readTerms(ctx contractapi.TransactionContextInterface, owner string, datasetID string) (*DatasetTerms, error) {
+	key, err := termsKey(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	terms := new(DatasetTerms)
+	if err := terms.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return terms, nil
+}
+
+// requireAcceptedTerms checks that the client has accepted the current terms the owner attached to
+// the dataset, and that they have not expired, before its endpoint is released
+func requireAcceptedTerms(ctx contractapi.TransactionContextInterface, owner string, datasetID string, mspID string, clientID string) (*TermsAcceptance, error) {
+	registered, err := isRegisteredBy(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if !registered {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, owner)
+	}
+	terms, err := readTerms(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if terms == nil {
+		return nil, fmt.Errorf(`Dataset "%s" has no terms, its endpoint is only released once terms are set and accepted.`, datasetID)
+	}
+	if terms.Owner != owner {
+		return nil, fmt.Errorf(`Terms of dataset "%s" are owned by Org "%s", not "%s".`, datasetID, terms.Owner, owner)
+	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if terms.expired(now) {
+		return nil, fmt.Errorf(`Terms of dataset "%s" expired at %s.`, datasetID, terms.Terms.ExpiresAt)
+	}
+
+	key, err := termsAcceptanceKey(ctx, owner, datasetID, mspID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := readFromPublic(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, fmt.Errorf(`Client "%s" of Org "%s" has not accepted the terms of dataset "%s".`, clientID, mspID, datasetID)
+	}
+	acceptance := new(TermsAcceptance)
+	if err := acceptance.FromBytes(bs); err != nil {
+		return nil, err
+	}
+	if acceptance.TermsHash != terms.Hash {
+		return nil, fmt.Errorf(`Client "%s" of Org "%s" accepted version %d of the terms of dataset "%s", version %d must be accepted.`, clientID, mspID, acceptance.TermsVersion, datasetID, terms.Version)
+	}
+
+	return acceptance, nil
+}
+
+// SetTerms attaches usage terms to a dataset of the client's organisation. Changing the terms
+// requires consumers to accept them again before the endpoint is released.
+func (l *DatasetMetadataLedger) SetTerms(ctx contractapi.TransactionContextInterface, datasetID string, termsJSON string) (*DatasetTerms, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opUpdate); err != nil {
+		return nil, err
+	}
+
+	reg, err := readRegistration(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if reg == nil {
+		return nil, fmt.Errorf(`Dataset "%s" is not registered by Org "%s".`, datasetID, mspID)
+	}
+	if err := requireOwnership(ctx, reg); err != nil {
+		return nil, err
+	}
+
+	content := new(TermsContent)
+	dec := json.NewDecoder(strings.NewReader(termsJSON))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(content); err != nil {
+		return nil, fmt.Errorf("Failed to decode terms.\n%v", err)
+	}
+	if content.ExpiresAt != "" {
+		if _, err := time.Parse(time.RFC3339, content.ExpiresAt); err != nil {
+			return nil, fmt.Errorf(`Terms expiry "%s" is not an RFC3339 date : %v`, content.ExpiresAt, err)
+		}
+	}
+	hash, err := content.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := readTerms(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	version := 1
+	if prev != nil {
+		version = prev.Version + 1
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := &DatasetTerms{
+		DatasetID: datasetID,
+		Owner:     mspID,
+		Terms:     *content,
+		Version:   version,
+		Hash:      hash,
+		UpdatedAt: ts,
+	}
+	bs, err := terms.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := termsKey(ctx, mspID, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to write terms of dataset "%s" : %v`, datasetID, err)
+	}
+
+	return terms, nil
+}
+
+// GetTerms returns the current terms the owner attached to a dataset, with their hash
+func (l *DatasetMetadataLedger) GetTerms(ctx contractapi.TransactionContextInterface, datasetID string, owner string) (*DatasetTerms, error) {
+	terms, err := readTerms(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if terms == nil {
+		return nil, fmt.Errorf(`Dataset "%s" of Org "%s" has no terms.`, datasetID, owner)
+	}
+
+	return terms, nil
+}
+
+// AcceptTerms binds the client identity to the terms the owner attached to a dataset with the given hash,
+// for a purpose the terms allow
+func (l *DatasetMetadataLedger) AcceptTerms(ctx contractapi.TransactionContextInterface, datasetID string, owner string, termsHash string, purpose string) (*TermsAcceptance, error) {
+	var mspID string
+	if err := requireIdenticalMSPID(ctx, &mspID); err != nil {
+		return nil, err
+	}
+
+	if err := requireCertification(ctx, opRequestAccess); err != nil {
+		return nil, err
+	}
+
+	terms, err := readTerms(ctx, owner, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if terms == nil {
+		return nil, fmt.Errorf(`Dataset "%s" of Org "%s" has no terms.`, datasetID, owner)
+	}
+	if termsHash != terms.Hash {
+		return nil, fmt.Errorf(`Terms hash "%s" does not match version %d of the terms of dataset "%s".`, termsHash, terms.Version, datasetID)
+	}
+	now, err := getTxTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if terms.expired(now) {
+		return nil, fmt.Errorf(`Terms of dataset "%s" expired at %s.`, datasetID, terms.Terms.ExpiresAt)
+	}
+	if len(terms.Terms.AllowedPurposes) > 0 && !containsString(terms.Terms.AllowedPurposes, purpose) {
+		return nil, fmt.Errorf(`Purpose "%s" is not allowed by the terms of dataset "%s", expected one of %v.`, purpose, datasetID, terms.Terms.AllowedPurposes)
+	}
+
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	acceptance := &TermsAcceptance{
+		DatasetID:      datasetID,
+		Owner:          owner,
+		Consumer:       mspID,
+		ConsumerClient: clientID,
+		TermsHash:      terms.Hash,
+		TermsVersion:   terms.Version,
+		Purpose:        purpose,
+		AcceptedAt:     ts,
+	}
+	bs, err := acceptance.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := termsAcceptanceKey(ctx, owner, datasetID, mspID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to record acceptance of the terms of dataset "%s" : %v`, datasetID, err)
+	}
+
+	if err := emitDatasetEvent(ctx, eventTermsAccepted, &DatasetEvent{
+		ID:        datasetID,
+		Owner:     terms.Owner,
+		Requester: mspID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return acceptance, nil
+}
@@ -0,0 +1,125 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestTermsOwner(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	// Org2 registers a dataset under the same ID in its own implicit collection, and attaches terms first
+	mustRegister(t, stub, org2Registrar, exampleMetadata)
+	org2Terms, err := l.SetTerms(as(t, stub, org2Registrar, nil), exampleID, `{"allowedPurposes": ["marketing"]}`)
+	if err != nil {
+		t.Fatalf("SetTerms: %v", err)
+	}
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+
+	tests := []struct {
+		name    string
+		id      *testIdentity
+		owner   string
+		version int
+		err     string
+	}{
+		{name: "owner attaches", id: org1Registrar, owner: "Org1MSP", version: 1},
+		{name: "owner replaces", id: org1Registrar, owner: "Org1MSP", version: 2},
+		{name: "other organisation keeps its own", id: org2Registrar, owner: "Org2MSP", version: 2},
+		{name: "not registered", id: org1Admin, err: `Dataset "org1.example.com/other" is not registered by Org "Org1MSP".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := exampleID
+			if tt.err != "" {
+				id = "org1.example.com/other"
+			}
+			terms, err := l.SetTerms(as(t, stub, tt.id, nil), id, `{"allowedPurposes": ["research"]}`)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetTerms: %v", err)
+			}
+			if terms.Owner != tt.owner || terms.Version != tt.version {
+				t.Errorf("terms = %+v", terms)
+			}
+		})
+	}
+
+	for _, owner := range []string{"Org1MSP", "Org2MSP"} {
+		terms, err := l.GetTerms(as(t, stub, org2Reader, nil), exampleID, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if terms.Owner != owner || terms.Version != 2 {
+			t.Errorf("terms of %s = %+v", owner, terms)
+		}
+	}
+	_, err = l.GetTerms(as(t, stub, org2Reader, nil), exampleID, "Org3MSP")
+	requireError(t, err, `Dataset "org1.example.com/data001" of Org "Org3MSP" has no terms.`)
+
+	t.Run("hash of another owner", func(t *testing.T) {
+		_, err := l.AcceptTerms(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", org2Terms.Hash, "marketing")
+		requireError(t, err, `does not match version 2 of the terms of dataset "org1.example.com/data001"`)
+	})
+}
+
+func TestGrantAccessRequiresAcceptedTerms(t *testing.T) {
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "")
+	mustRegister(t, stub, org2Registrar, exampleMetadata)
+	terms, err := l.SetTerms(as(t, stub, org1Registrar, nil), exampleID, `{"allowedPurposes": ["research"]}`)
+	if err != nil {
+		t.Fatalf("SetTerms: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		requester *testIdentity
+		accept    func() error
+		err       string
+	}{
+		{name: "not accepted", requester: org2Reader, err: `Client "x509::CN=reader2::CN=ca.Org2MSP" of Org "Org2MSP" has not accepted the terms`},
+		{name: "accepted by another client", requester: org2Registrar, accept: func() error {
+			_, err := l.AcceptTerms(as(t, stub, org2Reader, nil), exampleID, "Org1MSP", terms.Hash, "research")
+			return err
+		}, err: `Client "x509::CN=registrar2::CN=ca.Org2MSP" of Org "Org2MSP" has not accepted the terms`},
+		{name: "accepted by the requester", requester: org2Reader},
+		{name: "terms changed", requester: org2Reader, accept: func() error {
+			_, err := l.SetTerms(as(t, stub, org1Registrar, nil), exampleID, `{"allowedPurposes": ["research", "teaching"]}`)
+			return err
+		}, err: `accepted version 1 of the terms of dataset "org1.example.com/data001", version 2 must be accepted`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.accept != nil {
+				if err := tt.accept(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			req, err := l.RequestAccess(as(t, stub, tt.requester, nil), exampleID, "Org1MSP", "research")
+			if err != nil {
+				t.Fatalf("RequestAccess: %v", err)
+			}
+			err = l.GrantAccess(as(t, stub, org1Registrar, nil), exampleID, req.ID)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("GrantAccess: %v", err)
+			}
+		})
+	}
+
+	t.Run("terms changed after the grant", func(t *testing.T) {
+		_, err := l.QueryAccessGrant(as(t, stub, org2Reader, nil), exampleID, "Org1MSP")
+		requireError(t, err, "version 2 must be accepted")
+	})
+	t.Run("grant of another owner", func(t *testing.T) {
+		_, err := l.QueryAccessGrant(as(t, stub, org2Reader, nil), exampleID, "Org3MSP")
+		requireError(t, err, `Org "Org2MSP" has no access granted to dataset "org1.example.com/data001" of Org "Org3MSP".`)
+	})
+}
@@ -0,0 +1,79 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const tombstoneObjectType = "tombstone"
+
+// DatasetTombstone replaces a retired dataset in every place it was published to
+type DatasetTombstone struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	// Client ID of the retiring identity
+	RetiredBy string `json:"retiredBy"`
+	RetiredAt string `json:"retiredAt"`
+	Reason    string `json:"reason"`
+	// Last revision before retirement
+	Revision int `json:"revision"`
+}
+
+func (t *DatasetTombstone) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*t)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode tombstone to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+func (t *DatasetTombstone) FromBytes(bs []byte) error {
+	err := json.Unmarshal(bs, t)
+	if err != nil {
+		return fmt.Errorf("Failed to decode tombstone.\n%v", err)
+	}
+
+	return nil
+}
+
+func tombstoneKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return createCompositeKey(ctx, tombstoneObjectType, id)
+}
+
+// readTombstone returns nil if the dataset has not been retired from the target
+func readTombstone(ctx contractapi.TransactionContextInterface, target string, id string) (*DatasetTombstone, error) {
+	key, err := tombstoneKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := readFromTarget(ctx, target, key)
+	if err != nil {
+		return nil, err
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	t := new(DatasetTombstone)
+	if err := t.FromBytes(bs); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func requireNotRetired(ctx contractapi.TransactionContextInterface, target string, id string) error {
+	t, err := readTombstone(ctx, target, id)
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		return notFoundError(`Dataset "%s" was retired by "%s" at %s : %s`, t.ID, t.RetiredBy, t.RetiredAt, t.Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package contract
+
+import (
+	"testing"
+)
+
+func TestDeregister(t *testing.T) {
+	const collection = "publicDataBlockCollection"
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	mustRegister(t, stub, org1Registrar, exampleMetadata, "", collection)
+
+	otherRegistrar := &testIdentity{mspID: "Org1MSP", name: "registrar3", ouRoles: []string{"client"}, attributes: map[string]string{"dataset.registrar": "true"}}
+	for _, tt := range []struct {
+		name string
+		id   *testIdentity
+		err  string
+	}{
+		{name: "other registrar", id: otherRegistrar, err: `is neither the registrar of dataset "org1.example.com/data001" nor an admin of Org "Org1MSP"`},
+		{name: "other org", id: org2Registrar, err: `Dataset "org1.example.com/data001" is not registered by Org "Org2MSP"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireError(t, l.Deregister(as(t, stub, tt.id, nil), exampleID, "test"), tt.err)
+		})
+	}
+
+	if err := l.Deregister(as(t, stub, org1Registrar, nil), exampleID, "superseded"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+
+	t.Run("tombstones", func(t *testing.T) {
+		ctx := as(t, stub, org1Registrar, nil)
+		tsKey, err := tombstoneKey(ctx, exampleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range []string{"", collection, implicitPrivateDataCollection("Org1MSP")} {
+			if stub.get(target, exampleID) != nil {
+				t.Errorf("dataset left in collection %q", target)
+			}
+			bs := stub.get(target, tsKey)
+			if bs == nil {
+				t.Errorf("no tombstone in collection %q", target)
+				continue
+			}
+			tombstone := new(DatasetTombstone)
+			if err := tombstone.FromBytes(bs); err != nil {
+				t.Fatal(err)
+			}
+			if tombstone.Owner != "Org1MSP" || tombstone.Reason != "superseded" || tombstone.Revision != 1 {
+				t.Errorf("tombstone in collection %q = %+v", target, tombstone)
+			}
+		}
+		regKey, err := registrationKey(ctx, exampleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stub.get(implicitPrivateDataCollection("Org1MSP"), regKey) != nil {
+			t.Error("registration left in the implicit collection")
+		}
+	})
+
+	const retired = `Dataset "org1.example.com/data001" was retired by`
+	t.Run("query", func(t *testing.T) {
+		for _, target := range []string{"", collection} {
+			_, err := l.Query(as(t, stub, org2Reader, nil), target, exampleID)
+			requireError(t, err, retired)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		err := l.Update(as(t, stub, org1Registrar, map[string][]byte{"metadata": []byte(exampleMetadata)}))
+		requireError(t, err, retired)
+	})
+	t.Run("register", func(t *testing.T) {
+		err := l.Register(as(t, stub, org1Registrar, registerTransient(t, exampleMetadata)))
+		requireError(t, err, retired)
+	})
+	t.Run("deregister", func(t *testing.T) {
+		requireError(t, l.Deregister(as(t, stub, org1Admin, nil), exampleID, "again"), retired)
+	})
+}
@@ -0,0 +1,112 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const verificationObjectType = "verification"
+
+// VerificationRecord keeps the outcome of a consumer checking its copy of a dataset
+type VerificationRecord struct {
+	DatasetID  string `json:"datasetID"`
+	Collection string `json:"collection"`
+	Verifier   string `json:"verifier"`
+	// Client ID of the verifying identity
+	VerifierClient string `json:"verifierClient"`
+	SHA256         string `json:"sha256"`
+	MerkleRoot     string `json:"merkleRoot,omitempty"`
+	Match          bool   `json:"match"`
+	// Which digests differ, if any
+	Mismatches []string `json:"mismatches,omitempty"`
+	CheckedAt  string   `json:"checkedAt"`
+}
+
+func (r *VerificationRecord) ToBytes() ([]byte, error) {
+	bs, err := json.Marshal(*r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode verification to bytes.\n%v", err)
+	}
+
+	return bs, nil
+}
+
+// VerifyDataset compares digests computed by the consumer with the ones registered for the dataset
+// in the collection, and records the outcome on the public ledger. An empty merkleRoot is not compared.
+func (l *DatasetMetadataLedger) VerifyDataset(ctx contractapi.TransactionContextInterface, collection string, datasetID string, sha256 string, merkleRoot string) (*VerificationRecord, error) {
+	if err := requireCertification(ctx, opVerifyDataset); err != nil {
+		return nil, err
+	}
+
+	if err := requireNotRetired(ctx, collection, datasetID); err != nil {
+		return nil, err
+	}
+	if err := requireNotExpired(ctx, collection, datasetID); err != nil {
+		return nil, err
+	}
+	mdAsBytes, err := readFromTarget(ctx, collection, datasetID)
+	if err != nil {
+		return nil, err
+	}
+	if mdAsBytes == nil {
+		return nil, notFoundError(`Dataset "%s" not found in collection "%s".`, datasetID, collection)
+	}
+	md := new(DatasetMetadata)
+	if err := md.FromBytes(mdAsBytes); err != nil {
+		return nil, err
+	}
+	if md.Content == nil {
+		return nil, fmt.Errorf(`Dataset "%s" discloses no content digest in collection "%s".`, datasetID, collection)
+	}
+
+	sha256 = strings.ToLower(sha256)
+	merkleRoot = strings.ToLower(merkleRoot)
+	mismatches := []string{}
+	if sha256 != md.Content.SHA256 {
+		mismatches = append(mismatches, "sha256")
+	}
+	if merkleRoot != "" && merkleRoot != md.Content.MerkleRoot {
+		mismatches = append(mismatches, "merkleRoot")
+	}
+
+	mspID, err := getClientMSPID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := getClientID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := getTxTimestamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &VerificationRecord{
+		DatasetID:      datasetID,
+		Collection:     collection,
+		Verifier:       mspID,
+		VerifierClient: clientID,
+		SHA256:         sha256,
+		MerkleRoot:     merkleRoot,
+		Match:          len(mismatches) == 0,
+		Mismatches:     mismatches,
+		CheckedAt:      ts,
+	}
+	bs, err := record.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	key, err := createCompositeKey(ctx, verificationObjectType, datasetID, ctx.GetStub().GetTxID())
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.GetStub().PutState(key, bs); err != nil {
+		return nil, fmt.Errorf(`Failed to record verification of "%s" : %v`, datasetID, err)
+	}
+
+	return record, nil
+}
@@ -0,0 +1,70 @@
+package contract
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVerifyDataset(t *testing.T) {
+	const (
+		digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		root   = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+		other  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		noneID = "org1.example.com/nodigest"
+	)
+	stub := newTestStub()
+	l := new(DatasetMetadataLedger)
+	withContent := strings.Replace(exampleMetadata, `"tags"`, `"content": {"sha256": "`+digest+`", "merkleRoot": "`+root+`", "chunkSize": 1024, "size": 4096},
+	"tags"`, 1)
+	mustRegister(t, stub, org1Registrar, withContent, "")
+	mustRegister(t, stub, org1Registrar, metadataWithID(noneID), "")
+
+	tests := []struct {
+		name       string
+		id         string
+		sha256     string
+		merkleRoot string
+		mismatches []string
+		err        string
+	}{
+		{name: "match", id: exampleID, sha256: digest, merkleRoot: root},
+		{name: "match without merkle root", id: exampleID, sha256: digest},
+		{name: "match in upper case", id: exampleID, sha256: strings.ToUpper(digest)},
+		{name: "sha256 mismatch", id: exampleID, sha256: other, merkleRoot: root, mismatches: []string{"sha256"}},
+		{name: "both mismatch", id: exampleID, sha256: other, merkleRoot: other, mismatches: []string{"sha256", "merkleRoot"}},
+		{name: "no digest", id: noneID, sha256: digest, err: `Dataset "org1.example.com/nodigest" discloses no content digest in collection ""`},
+		{name: "missing", id: "org1.example.com/none", sha256: digest, err: `Dataset "org1.example.com/none" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := l.VerifyDataset(as(t, stub, org2Reader, nil), "", tt.id, tt.sha256, tt.merkleRoot)
+			if tt.err != "" {
+				requireError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyDataset: %v", err)
+			}
+			if record.Match != (len(tt.mismatches) == 0) || strings.Join(record.Mismatches, ",") != strings.Join(tt.mismatches, ",") {
+				t.Errorf("match = %v, mismatches = %v, want %v", record.Match, record.Mismatches, tt.mismatches)
+			}
+
+			key, err := stub.CreateCompositeKey(verificationObjectType, []string{tt.id, stub.txID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bs := stub.get("", key)
+			if bs == nil {
+				t.Fatal("no verification record on the public ledger")
+			}
+			stored := new(VerificationRecord)
+			if err := json.Unmarshal(bs, stored); err != nil {
+				t.Fatal(err)
+			}
+			if stored.Verifier != "Org2MSP" || stored.SHA256 != strings.ToLower(tt.sha256) || stored.Match != record.Match || stored.CheckedAt == "" {
+				t.Errorf("stored record = %+v", stored)
+			}
+		})
+	}
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	_ "embed"
 	"log"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/jxu96/fabric-samples/chaincode/data-block-manager/contract"
 )
 
+// the collection configuration the chaincode is deployed with
+//
+//go:embed collection_config.json
+var collectionConfig []byte
+
 func main() {
+	if err := contract.LoadCollectionConfig(collectionConfig); err != nil {
+		log.Panicf("Error loading collection configuration: %v", err)
+	}
+
 	chaincode, err := contractapi.NewChaincode(&contract.DatasetMetadataLedger{})
 	if err != nil {
 		log.Panicf("Error creating data-block-manager chaincode: %v", err)
@@ -0,0 +1,180 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// accessCmd represents the access command
+var accessCmd = &cobra.Command{
+	Use:   "access",
+	Short: "Request and grant access to private dataset endpoints",
+	Long: `Manage access requests for the private endpoint of a dataset.
+
+A consumer organisation files a request with "access request", the owner then
+grants, denies or later revokes it. Once granted, the endpoint and access terms
+can be read with "access show".`,
+}
+
+var accessRequestCmd = &cobra.Command{
+	Use:   "request [dataset-id] [owner-msp-id]",
+	Short: "Request access to a dataset of another organisation",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		purpose, err := cmd.Flags().GetString("purpose")
+		cobra.CheckErr(err)
+
+		submitAccess("RequestAccess", nil, args[0], args[1], purpose)
+	},
+}
+
+var accessGrantCmd = &cobra.Command{
+	Use:   "grant [dataset-id] [request-id]",
+	Short: "Grant a pending access request",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		termsPath, err := cmd.Flags().GetString("terms")
+		cobra.CheckErr(err)
+		transientData := map[string][]byte{}
+		if termsPath != "" {
+			terms, err := os.ReadFile(termsPath)
+			cobra.CheckErr(err)
+			transientData["terms"] = terms
+		}
+
+		submitAccess("GrantAccess", transientData, args[0], args[1])
+	},
+}
+
+var accessDenyCmd = &cobra.Command{
+	Use:   "deny [dataset-id] [request-id]",
+	Short: "Deny a pending access request",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		reason, err := cmd.Flags().GetString("reason")
+		cobra.CheckErr(err)
+
+		submitAccess("DenyAccess", nil, args[0], args[1], reason)
+	},
+}
+
+var accessRevokeCmd = &cobra.Command{
+	Use:   "revoke [dataset-id] [request-id]",
+	Short: "Revoke a granted access request",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		reason, err := cmd.Flags().GetString("reason")
+		cobra.CheckErr(err)
+
+		submitAccess("RevokeAccess", nil, args[0], args[1], reason)
+	},
+}
+
+var accessListCmd = &cobra.Command{
+	Use:   "list [dataset-id]",
+	Short: "List the access requests filed for a dataset",
+	Long: `List the access requests filed for a dataset. The owning organisation sees
+every request addressed to it, other clients only the requests they filed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("QueryAccessRequests", args[0])
+	},
+}
+
+var accessShowCmd = &cobra.Command{
+	Use:   "show [dataset-id] [owner-msp-id]",
+	Short: "Show the endpoint and terms shared with your organisation",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("QueryAccessGrant", args[0], args[1])
+	},
+}
+
+var accessRecordCmd = &cobra.Command{
+	Use:   "record [dataset-id] [owner-msp-id]",
+	Short: "Record a fetch of a dataset in the access log of its owner",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		purpose, err := cmd.Flags().GetString("purpose")
+		cobra.CheckErr(err)
+
+		submitAccess("RecordAccess", nil, args[0], args[1], purpose)
+	},
+}
+
+var accessLogCmd = &cobra.Command{
+	Use:   "log [dataset-id]",
+	Short: "Show who fetched a dataset of your organisation",
+	Long: `Show the access log of a dataset of your organisation, oldest first and one
+page at a time. --from and --to take RFC3339 dates and bound the period.
+The result carries a bookmark, pass it with --bookmark to fetch the next page.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := cmd.Flags().GetString("from")
+		cobra.CheckErr(err)
+		to, err := cmd.Flags().GetString("to")
+		cobra.CheckErr(err)
+		pageSize, err := cmd.Flags().GetInt("page-size")
+		cobra.CheckErr(err)
+		bookmark, err := cmd.Flags().GetString("bookmark")
+		cobra.CheckErr(err)
+
+		evaluateAccess("GetAccessLog", args[0], from, to, strconv.Itoa(pageSize), bookmark)
+	},
+}
+
+func submitAccess(name string, transientData map[string][]byte, args ...string) {
+	gatewayConfig := getGatewayConfig()
+	gw := gateway.NewFabricGateway()
+	cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+	defer gw.Client.Close()
+	defer gw.Gateway.Close()
+
+	result, err := getContract(gw).Submit(
+		name,
+		client.WithArguments(args...),
+		client.WithTransient(transientData),
+	)
+	cobra.CheckErr(err)
+	if len(result) > 0 {
+		fmt.Printf("Result: %s\n", string(result))
+	}
+}
+
+func evaluateAccess(name string, args ...string) {
+	gatewayConfig := getGatewayConfig()
+	gw := gateway.NewFabricGateway()
+	cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+	defer gw.Client.Close()
+	defer gw.Gateway.Close()
+
+	result, err := getContract(gw).Evaluate(
+		name,
+		client.WithArguments(args...),
+	)
+	cobra.CheckErr(err)
+	fmt.Printf("Result: %s\n", string(result))
+}
+
+func init() {
+	rootCmd.AddCommand(accessCmd)
+	accessCmd.AddCommand(accessRequestCmd, accessGrantCmd, accessDenyCmd, accessRevokeCmd, accessListCmd, accessShowCmd, accessRecordCmd, accessLogCmd)
+
+	accessRequestCmd.Flags().String("purpose", "", "purpose of the access")
+	accessGrantCmd.Flags().String("terms", "", "path to access terms file shared with the requester")
+	accessDenyCmd.Flags().String("reason", "", "reason for denying the request")
+	accessRevokeCmd.Flags().String("reason", "", "reason for revoking the access")
+	accessRecordCmd.Flags().String("purpose", "", "purpose of the fetch")
+	accessLogCmd.Flags().String("from", "", "start of the period, inclusive")
+	accessLogCmd.Flags().String("to", "", "end of the period, exclusive")
+	accessLogCmd.Flags().Int("page-size", 50, "number of entries per page")
+	accessLogCmd.Flags().String("bookmark", "", "bookmark returned by the previous page")
+}
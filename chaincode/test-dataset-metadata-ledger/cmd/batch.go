@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// limits of a single RegisterBatch transaction, the item count matches the chaincode
+const (
+	defaultBatchSize  = 100
+	maxBatchSize      = 500
+	defaultBatchBytes = 1 << 20
+)
+
+// readMetadataDocuments returns every metadata document in a file or in the .json files of a
+// directory. A file may hold a single document, a JSON array or several concatenated documents.
+func readMetadataDocuments(path string) ([]json.RawMessage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readMetadataFile(path)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	ndjson, err := filepath.Glob(filepath.Join(path, "*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, ndjson...)
+	sort.Strings(paths)
+
+	docs := []json.RawMessage{}
+	for _, p := range paths {
+		fileDocs, err := readMetadataFile(p)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no metadata files found in %s", path)
+	}
+
+	return docs, nil
+}
+
+func readMetadataFile(path string) ([]json.RawMessage, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	bs = bytes.TrimSpace(bs)
+	if len(bs) > 0 && bs[0] == '[' {
+		var docs []json.RawMessage
+		if err := json.Unmarshal(bs, &docs); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return docs, nil
+	}
+
+	docs := []json.RawMessage{}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	for {
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// splitBatches groups documents into NDJSON payloads of at most maxItems documents and,
+// unless a single document is larger, maxBytes bytes
+func splitBatches(docs []json.RawMessage, maxItems int, maxBytes int) [][]byte {
+	batches := [][]byte{}
+	var current bytes.Buffer
+	count := 0
+	for _, doc := range docs {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, doc); err != nil {
+			compact.Reset()
+			compact.Write(doc)
+		}
+		if count > 0 && (count >= maxItems || current.Len()+compact.Len()+1 > maxBytes) {
+			batches = append(batches, append([]byte{}, current.Bytes()...))
+			current.Reset()
+			count = 0
+		}
+		current.Write(compact.Bytes())
+		current.WriteByte('\n')
+		count++
+	}
+	if count > 0 {
+		batches = append(batches, current.Bytes())
+	}
+
+	return batches
+}
+
+// batchRejectedCode prefixes the chaincode message of a rejected batch, the failures follow as JSON
+const batchRejectedCode = "BATCH_REJECTED: "
+
+// batchRejection lists the items that made the chaincode reject a batch, nothing of which was registered
+type batchRejection struct {
+	Total    int `json:"total"`
+	Failures []struct {
+		Index int    `json:"index"`
+		ID    string `json:"id"`
+		Error string `json:"error"`
+	} `json:"failures"`
+}
+
+// readBatchRejection finds the failures of a rejected batch among the messages of the error
+func readBatchRejection(err error) (*batchRejection, bool) {
+	for _, message := range errorMessages(err) {
+		i := strings.Index(message, batchRejectedCode)
+		if i < 0 {
+			continue
+		}
+		rejection := new(batchRejection)
+		if json.NewDecoder(strings.NewReader(message[i+len(batchRejectedCode):])).Decode(rejection) == nil {
+			return rejection, true
+		}
+	}
+
+	return nil, false
+}
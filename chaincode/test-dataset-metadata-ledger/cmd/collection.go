@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+)
+
+// collectionCmd represents the collection command
+var collectionCmd = &cobra.Command{
+	Use:   "collection",
+	Short: "Manage the collection registry and share datasets",
+	Long: `Datasets can only be written to collections registered on the ledger whose
+members include your organisation, besides the public ledger and your own
+implicit collection.
+
+Propose the members of a collection defined in collection_config.json with
+"collection set". The members are registered once every current and proposed
+member organisation has run "collection set" with the same members, check the
+approvals with "collection proposal". Then publish existing datasets into the
+collection with "collection share".`,
+}
+
+var collectionSetCmd = &cobra.Command{
+	Use:   "set [name]",
+	Short: "Propose or approve the member organisations of a collection",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		members, err := cmd.Flags().GetStringArray("member")
+		cobra.CheckErr(err)
+		bs, err := json.Marshal(members)
+		cobra.CheckErr(err)
+
+		submitAccess("SetCollection", nil, args[0], string(bs))
+	},
+}
+
+var collectionShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the member organisations of a collection",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("GetCollection", args[0])
+	},
+}
+
+var collectionProposalCmd = &cobra.Command{
+	Use:   "proposal [name]",
+	Short: "Show the pending change of the members of a collection and its approvals",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("GetCollectionProposal", args[0])
+	},
+}
+
+var collectionShareCmd = &cobra.Command{
+	Use:   "share [dataset-id] [collection...]",
+	Short: "Publish a registered dataset into more collections",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, collection := range args[1:] {
+			submitAccess("ShareWith", nil, args[0], collection)
+		}
+	},
+}
+
+var collectionListCmd = &cobra.Command{
+	Use:   "list [dataset-id]",
+	Short: "List the collections a dataset is shared with",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("ListSharedCollections", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(collectionCmd)
+	collectionCmd.AddCommand(collectionSetCmd, collectionShowCmd, collectionProposalCmd, collectionShareCmd, collectionListCmd)
+
+	collectionSetCmd.Flags().StringArray("member", []string{}, "MSP ID of a member organisation")
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// sources of a resolved setting, from the highest precedence to the lowest
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// profileSettings are the settings a profile of the config file can bundle
+var profileSettings = []string{"as", "peer", "channel", "chaincode"}
+
+// resolvedSetting is the value of a setting and where it was taken from
+type resolvedSetting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// settingEnv returns the environment variable of a profile setting, e.g. FABRIC_CHANNEL
+func settingEnv(name string) string {
+	return "FABRIC_" + strings.ToUpper(name)
+}
+
+// keyEnv returns the environment variable viper reads for a config key, e.g. ORG1_PEER0_ENDPOINT
+func keyEnv(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// resolveProfile returns the name of the selected profile, empty if none is selected
+func resolveProfile() resolvedSetting {
+	setting := resolvedSetting{Name: "profile", Source: sourceDefault}
+	if flag := rootCmd.PersistentFlags().Lookup("profile"); flag.Changed {
+		setting.Value, setting.Source = flag.Value.String(), sourceFlag
+	} else if value, ok := os.LookupEnv(settingEnv("profile")); ok {
+		setting.Value, setting.Source = value, sourceEnv
+	} else if viper.InConfig("profile") {
+		setting.Value, setting.Source = viper.GetString("profile"), sourceFile
+	}
+
+	return setting
+}
+
+// resolveSetting resolves a profile setting from its flag, its environment variable, the selected
+// profile, the top level of the config file and the flag default, in that order
+func resolveSetting(name string) resolvedSetting {
+	setting, err := lookupSetting(name)
+	cobra.CheckErr(err)
+
+	return setting
+}
+
+// lookupSetting resolves a profile setting like resolveSetting, failing if the selected profile is
+// not defined in the config file
+func lookupSetting(name string) (resolvedSetting, error) {
+	flag := rootCmd.PersistentFlags().Lookup(name)
+	if flag.Changed {
+		return resolvedSetting{Name: name, Value: flag.Value.String(), Source: sourceFlag}, nil
+	}
+	if value, ok := os.LookupEnv(settingEnv(name)); ok {
+		return resolvedSetting{Name: name, Value: value, Source: sourceEnv}, nil
+	}
+
+	profile := resolveProfile()
+	if profile.Value != "" {
+		if !viper.InConfig("profiles." + profile.Value) {
+			return resolvedSetting{}, fmt.Errorf("profile %q is not defined in the config file", profile.Value)
+		}
+		key := "profiles." + profile.Value + "." + name
+		if viper.InConfig(key) {
+			return resolvedSetting{Name: name, Value: viper.GetString(key), Source: sourceFile}, nil
+		}
+	}
+	if viper.InConfig(name) {
+		return resolvedSetting{Name: name, Value: viper.GetString(name), Source: sourceFile}, nil
+	}
+
+	return resolvedSetting{Name: name, Value: flag.DefValue, Source: sourceDefault}, nil
+}
+
+// resolveKey reports where viper takes the value of a config key from
+func resolveKey(key string) resolvedSetting {
+	source := sourceDefault
+	if _, ok := os.LookupEnv(keyEnv(key)); ok {
+		source = sourceEnv
+	} else if viper.InConfig(key) {
+		source = sourceFile
+	}
+
+	return resolvedSetting{Name: key, Value: viper.GetString(key), Source: source}
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration of the CLI",
+	Long: `Settings are resolved from flags, environment variables, the selected profile
+of the config file, the top level of the config file and defaults, in that order.
+
+A profile bundles the identity, peer, channel and chaincode to use, for example:
+
+profile: prod
+profiles:
+  prod:
+    as: org1.user1
+    peer: org1.peer0
+    channel: datasets-channel
+    chaincode: datasets
+
+Select a profile with --profile or FABRIC_PROFILE, or set any setting directly
+with its flag or FABRIC_AS, FABRIC_PEER, FABRIC_CHANNEL and FABRIC_CHAINCODE.
+
+The identity is read from the PEM files of the user, e.g. org1.user1.certPath
+and org1.user1.keyPath, from a fabric-sdk-go wallet if org1.user1.wallet is set,
+with org1.user1.label naming the identity, or from FABRIC_CERT_PEM and
+FABRIC_KEY_PEM if they are set.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the resolved settings and where each was taken from",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		settings := []resolvedSetting{resolveProfile()}
+		for _, name := range profileSettings {
+			settings = append(settings, resolveSetting(name))
+		}
+
+		// connection details of the resolved identity and peer
+		user := settings[1].Value
+		peer := settings[2].Value
+		org := strings.Split(user, ".")[0]
+		for _, key := range []string{
+			org + ".mspID",
+			user + ".certPath",
+			user + ".keyPath",
+			peer + ".tlsCertPath",
+			peer + ".endpoint",
+			peer + ".gateway",
+		} {
+			settings = append(settings, resolveKey(key))
+		}
+
+		if file := viper.ConfigFileUsed(); file != "" {
+			fmt.Printf("Config file: %s\n", file)
+		}
+		for _, s := range settings {
+			fmt.Printf("%-24s %-48s (%s)\n", s.Name, s.Value, s.Source)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testConfig = `
+channel: top-channel
+profiles:
+  prod:
+    channel: prod-channel
+    chaincode: prod-chaincode
+  dev:
+    chaincode: dev-chaincode
+org1:
+  peer0:
+    endpoint: peer0.example.com:7051
+`
+
+// withSettings sets up the config file, environment variables and flags of a test, and restores
+// them once it ends
+func withSettings(t *testing.T, config string, env map[string]string, flags map[string]string) {
+	t.Helper()
+	viper.SetConfigType("yaml")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	if err := viper.ReadConfig(strings.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { viper.ReadConfig(strings.NewReader("")) })
+
+	for _, name := range append([]string{"profile"}, profileSettings...) {
+		t.Setenv(settingEnv(name), "")
+		os.Unsetenv(settingEnv(name))
+	}
+	t.Setenv(keyEnv("org1.peer0.endpoint"), "")
+	os.Unsetenv(keyEnv("org1.peer0.endpoint"))
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	for name, value := range flags {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if err := flag.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		flag.Changed = true
+		t.Cleanup(func() {
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		})
+	}
+}
+
+func TestLookupSetting(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]string
+		flags  map[string]string
+		value  string
+		source string
+		err    string
+	}{
+		{name: "default", value: "mychannel", source: sourceDefault},
+		{name: "top level", config: testConfig, value: "top-channel", source: sourceFile},
+		{name: "profile of the file", config: "profile: prod\n" + testConfig, value: "prod-channel", source: sourceFile},
+		{name: "profile without the setting", config: "profile: dev\n" + testConfig, value: "top-channel", source: sourceFile},
+		{name: "profile by env", config: testConfig, env: map[string]string{"FABRIC_PROFILE": "prod"}, value: "prod-channel", source: sourceFile},
+		{name: "profile by flag", config: "profile: dev\n" + testConfig, env: map[string]string{"FABRIC_PROFILE": "dev"}, flags: map[string]string{"profile": "prod"}, value: "prod-channel", source: sourceFile},
+		{name: "env over profile", config: "profile: prod\n" + testConfig, env: map[string]string{"FABRIC_CHANNEL": "env-channel"}, value: "env-channel", source: sourceEnv},
+		{name: "flag over env", config: "profile: prod\n" + testConfig, env: map[string]string{"FABRIC_CHANNEL": "env-channel"}, flags: map[string]string{"channel": "flag-channel"}, value: "flag-channel", source: sourceFlag},
+		{name: "undefined profile", config: testConfig, flags: map[string]string{"profile": "test"}, err: `profile "test" is not defined in the config file`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withSettings(t, tt.config, tt.env, tt.flags)
+
+			setting, err := lookupSetting("channel")
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if setting.Value != tt.value || setting.Source != tt.source {
+				t.Errorf("channel = %q from %s, want %q from %s", setting.Value, setting.Source, tt.value, tt.source)
+			}
+		})
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	withSettings(t, "profile: prod\n"+testConfig,
+		map[string]string{"FABRIC_PEER": "org1.peer0", "ORG1_PEER0_ENDPOINT": "localhost:8051"},
+		map[string]string{"as": "org1.user1"})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	configShowCmd.Run(configShowCmd, nil)
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"profile":             "prod (file)",
+		"as":                  "org1.user1 (flag)",
+		"peer":                "org1.peer0 (env)",
+		"channel":             "prod-channel (file)",
+		"chaincode":           "prod-chaincode (file)",
+		"org1.mspID":          "Org1MSP (default)",
+		"org1.peer0.endpoint": "localhost:8051 (env)",
+		"org1.peer0.gateway":  "peer0.org1.example.com (default)",
+	} {
+		line := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(name) + ` +(.*)$`).FindStringSubmatch(string(out))
+		if line == nil {
+			t.Errorf("%s missing from\n%s", name, out)
+			continue
+		}
+		if got := strings.Join(strings.Fields(line[1]), " "); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSampleRows is the number of CSV rows read to infer column types
+const defaultSampleRows = 1000
+
+// columnSchema mirrors the column schema of the chaincode
+type columnSchema struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+	Nullable    bool   `json:"nullable"`
+	PII         bool   `json:"pii"`
+}
+
+// column types from the narrowest to the widest, a column takes the narrowest type all its values fit
+var inferredTypes = []struct {
+	name  string
+	match func(string) bool
+}{
+	{"boolean", func(v string) bool { return strings.EqualFold(v, "true") || strings.EqualFold(v, "false") }},
+	{"integer", func(v string) bool { _, err := strconv.ParseInt(v, 10, 64); return err == nil }},
+	{"number", func(v string) bool { _, err := strconv.ParseFloat(v, 64); return err == nil }},
+	{"date", func(v string) bool { _, err := time.Parse("2006-01-02", v); return err == nil }},
+	{"datetime", func(v string) bool { _, err := time.Parse(time.RFC3339, v); return err == nil }},
+}
+
+// inferColumns reads the header and up to sampleRows rows of a CSV file and infers the type and
+// nullability of each column, values that fit no other type make a string column. Columns without
+// any value in the sample are nullable strings.
+func inferColumns(path string, sampleRows int) ([]*columnSchema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header of %s: %v", path, err)
+	}
+	columns := make([]*columnSchema, len(header))
+	// candidates[i] holds the indexes of inferredTypes every value of column i fits so far
+	candidates := make([][]int, len(header))
+	// filled[i] counts the non-empty values of column i in the sample
+	filled := make([]int, len(header))
+	for i, name := range header {
+		columns[i] = &columnSchema{Name: strings.TrimSpace(name)}
+		for t := range inferredTypes {
+			candidates[i] = append(candidates[i], t)
+		}
+	}
+
+	for n := 0; n < sampleRows; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		for i := range columns {
+			value := ""
+			if i < len(record) {
+				value = strings.TrimSpace(record[i])
+			}
+			if value == "" {
+				columns[i].Nullable = true
+				continue
+			}
+			filled[i]++
+			kept := candidates[i][:0]
+			for _, t := range candidates[i] {
+				if inferredTypes[t].match(value) {
+					kept = append(kept, t)
+				}
+			}
+			candidates[i] = kept
+		}
+	}
+
+	for i, c := range columns {
+		c.Type = "string"
+		if filled[i] == 0 {
+			c.Nullable = true
+		} else if len(candidates[i]) > 0 {
+			c.Type = inferredTypes[candidates[i][0]].name
+		}
+	}
+
+	return columns, nil
+}
+
+// withColumns sets the column schema and field names of the metadata, keeping the unit, description
+// and PII flag of columns already described in it. Columns named in pii are flagged as PII.
+func withColumns(md []byte, columns []*columnSchema, pii []string) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(md, &fields); err != nil {
+		return nil, err
+	}
+
+	described := map[string]*columnSchema{}
+	if prev, ok := fields["columns"]; ok {
+		bs, err := json.Marshal(prev)
+		if err != nil {
+			return nil, err
+		}
+		var prevColumns []*columnSchema
+		if err := json.Unmarshal(bs, &prevColumns); err != nil {
+			return nil, fmt.Errorf("failed to decode columns of the metadata: %v", err)
+		}
+		for _, c := range prevColumns {
+			described[c.Name] = c
+		}
+	}
+
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		if prev, ok := described[c.Name]; ok {
+			c.Unit = prev.Unit
+			c.Description = prev.Description
+			c.PII = prev.PII
+		}
+		if containsArg(pii, c.Name) {
+			c.PII = true
+		}
+		names[i] = c.Name
+	}
+	for _, name := range pii {
+		if !containsArg(names, name) {
+			return nil, fmt.Errorf("PII column %q is not in the CSV header", name)
+		}
+	}
+	fields["columns"] = columns
+	fields["fieldNames"] = names
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInferColumns(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		sampleRows int
+		types      []string
+		nullable   []bool
+	}{
+		{
+			name:       "types",
+			csv:        "flag,count,ratio,day,at,label\ntrue,1,0.5,2022-01-01,2022-01-01T09:00:00Z,a\nFALSE,-2,3,2022-12-31,2022-01-02T09:00:00+01:00,1\n",
+			sampleRows: defaultSampleRows,
+			types:      []string{"boolean", "integer", "number", "date", "datetime", "string"},
+			nullable:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name:       "empty values",
+			csv:        "id,note,empty\n1,,\n2,x,\n",
+			sampleRows: defaultSampleRows,
+			types:      []string{"integer", "string", "string"},
+			nullable:   []bool{false, true, true},
+		},
+		{
+			name:       "header only",
+			csv:        "id,note\n",
+			sampleRows: defaultSampleRows,
+			types:      []string{"string", "string"},
+			nullable:   []bool{true, true},
+		},
+		{
+			name:       "value beyond sample",
+			csv:        "id\n1\nx\n",
+			sampleRows: 1,
+			types:      []string{"integer"},
+			nullable:   []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0600); err != nil {
+				t.Fatal(err)
+			}
+			columns, err := inferColumns(path, tt.sampleRows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(columns) != len(tt.types) {
+				t.Fatalf("got %d columns, want %d", len(columns), len(tt.types))
+			}
+			for i, c := range columns {
+				if c.Type != tt.types[i] || c.Nullable != tt.nullable[i] {
+					t.Errorf("column %s is %s, nullable %v, want %s, nullable %v", c.Name, c.Type, c.Nullable, tt.types[i], tt.nullable[i])
+				}
+			}
+		})
+	}
+}
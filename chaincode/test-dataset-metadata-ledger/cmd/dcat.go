@@ -0,0 +1,440 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// input formats accepted by register
+const (
+	formatLedger = "ledger"
+	formatDCAT   = "dcat"
+	formatCKAN   = "ckan"
+)
+
+// licenses known to the ledger, keyed by lowercase SPDX identifier, SPDX URL or CKAN license_id
+var importLicenses = map[string]string{
+	"odc-pddl":        "ODC-PDDL",
+	"pddl-1.0":        "PDDL-1.0",
+	"odc-by":          "ODC-By",
+	"odc-by-1.0":      "ODC-By-1.0",
+	"odc-odbl":        "ODbL",
+	"odbl":            "ODbL",
+	"odbl-1.0":        "ODbL-1.0",
+	"cc-zero":         "CC0-1.0",
+	"cc0-1.0":         "CC0-1.0",
+	"cc-by":           "CC-BY-4.0",
+	"cc-by-4.0":       "CC-BY-4.0",
+	"cc-by-sa":        "CC-BY-SA-4.0",
+	"cc-by-sa-4.0":    "CC-BY-SA-4.0",
+	"cc-nc":           "CC-BY-NC-4.0",
+	"cc-by-nc-4.0":    "CC-BY-NC-4.0",
+	"cc-by-nd-4.0":    "CC-BY-ND-4.0",
+	"cc-by-nc-sa-4.0": "CC-BY-NC-SA-4.0",
+	"apache-2.0":      "Apache-2.0",
+	"mit":             "MIT",
+	"gpl-3.0":         "GPL-3.0-only",
+	"gpl-3.0-only":    "GPL-3.0-only",
+}
+
+var slugPattern = regexp.MustCompile(`[^A-Za-z0-9._~-]+`)
+
+var datasetIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*/[A-Za-z0-9._~-]+(/[A-Za-z0-9._~-]+)*$`)
+
+// MappingReport lists what an import could not carry over into the ledger metadata
+type MappingReport struct {
+	ID       string
+	Unmapped []string
+	Notes    []string
+}
+
+func (r *MappingReport) String() string {
+	if len(r.Unmapped) == 0 && len(r.Notes) == 0 {
+		return fmt.Sprintf("%s: all fields mapped", r.ID)
+	}
+	lines := []string{fmt.Sprintf("%s:", r.ID)}
+	for _, note := range r.Notes {
+		lines = append(lines, "  "+note)
+	}
+	if len(r.Unmapped) > 0 {
+		lines = append(lines, "  unmapped: "+strings.Join(r.Unmapped, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// importMetadata converts a DCAT or CKAN package document into ledger metadata. Datasets whose
+// identifier is not of the form "org/path" get an ID under the organisation prefix.
+func importMetadata(doc []byte, format string, orgPrefix string) ([]byte, *MappingReport, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, nil, err
+	}
+
+	var md map[string]interface{}
+	var report *MappingReport
+	switch format {
+	case formatDCAT:
+		md, report = fromDCAT(stripPrefixes(fields))
+	case formatCKAN:
+		// package_show responses wrap the package in "result"
+		if result, ok := fields["result"].(map[string]interface{}); ok {
+			fields = result
+		}
+		md, report = fromCKAN(fields)
+	default:
+		return nil, nil, fmt.Errorf("unknown import format %q", format)
+	}
+
+	id := text(md["id"])
+	if id == "" {
+		return nil, report, fmt.Errorf("dataset has no identifier")
+	}
+	if !datasetIDPattern.MatchString(id) {
+		prefix := orgPrefix
+		if prefix == "" {
+			prefix = slug(text(md["organisation"]))
+		}
+		if prefix == "" {
+			return nil, report, fmt.Errorf("dataset %q has no organisation, set --id-prefix", id)
+		}
+		md["id"] = prefix + "/" + slug(id)
+		report.Notes = append(report.Notes, fmt.Sprintf("id %q rewritten to %q", id, md["id"]))
+	}
+	report.ID = text(md["id"])
+	sort.Strings(report.Unmapped)
+
+	bs, err := json.Marshal(md)
+	if err != nil {
+		return nil, report, err
+	}
+	return bs, report, nil
+}
+
+// stripPrefixes drops JSON-LD prefixes such as "dct:" and the @context, recursively
+func stripPrefixes(fields map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range fields {
+		if key == "@context" {
+			continue
+		}
+		if i := strings.LastIndex(key, ":"); i >= 0 && !strings.HasPrefix(key, "@") {
+			key = key[i+1:]
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = stripPrefixes(v)
+		case []interface{}:
+			items := make([]interface{}, len(v))
+			for i, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					items[i] = stripPrefixes(m)
+				} else {
+					items[i] = item
+				}
+			}
+			value = items
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func fromDCAT(fields map[string]interface{}) (map[string]interface{}, *MappingReport) {
+	report := &MappingReport{}
+	md := map[string]interface{}{}
+	mapped := map[string]bool{"@type": true, "@id": true}
+	take := func(key string) (interface{}, bool) {
+		value, ok := fields[key]
+		if ok {
+			mapped[key] = true
+		}
+		return value, ok
+	}
+
+	if v, ok := take("identifier"); ok {
+		md["id"] = text(v)
+	} else if v, ok := fields["@id"]; ok {
+		md["id"] = text(v)
+	}
+	setText(md, "title", take, "title")
+	setText(md, "description", take, "description")
+	if v, ok := take("publisher"); ok {
+		md["organisation"] = agentName(v)
+	}
+	if v, ok := take("contactPoint"); ok {
+		md["maintainer"] = contactOf(v)
+	}
+	if v, ok := take("issued"); ok {
+		setDate(md, text(v), report)
+	} else if v, ok := take("modified"); ok {
+		setDate(md, text(v), report)
+	}
+	setText(md, "location", take, "spatial")
+	setText(md, "updateFrequency", take, "accrualPeriodicity")
+	setText(md, "source", take, "source")
+	setText(md, "methodology", take, "provenance")
+	if v, ok := take("keyword"); ok {
+		md["tags"] = texts(v)
+	} else if v, ok := take("theme"); ok {
+		md["tags"] = texts(v)
+	}
+	if v, ok := take("license"); ok {
+		setLicense(md, text(v), report)
+	}
+
+	if v, ok := take("distribution"); ok {
+		dists := items(v)
+		for i, dist := range dists {
+			d, _ := dist.(map[string]interface{})
+			if i > 0 {
+				report.Notes = append(report.Notes, fmt.Sprintf("distribution %d dropped, only the first endpoint is kept", i))
+				appendFormat(md, d["format"], d["mediaType"])
+				continue
+			}
+			for key := range d {
+				switch key {
+				case "@type", "title", "accessURL", "downloadURL", "format", "mediaType":
+				default:
+					report.Unmapped = append(report.Unmapped, "distribution."+key)
+				}
+			}
+			if t := text(d["title"]); t != "" {
+				md["name"] = t
+			}
+			if u := text(d["accessURL"]); u != "" {
+				md["endpoint"] = u
+			} else if u := text(d["downloadURL"]); u != "" {
+				md["endpoint"] = u
+			}
+			appendFormat(md, d["format"], d["mediaType"])
+		}
+	}
+
+	for key := range fields {
+		if !mapped[key] {
+			report.Unmapped = append(report.Unmapped, key)
+		}
+	}
+	return md, report
+}
+
+func fromCKAN(fields map[string]interface{}) (map[string]interface{}, *MappingReport) {
+	report := &MappingReport{}
+	md := map[string]interface{}{}
+	mapped := map[string]bool{
+		// bookkeeping of the CKAN instance
+		"id": true, "state": true, "type": true, "private": true, "owner_org": true,
+		"num_resources": true, "num_tags": true, "creator_user_id": true, "revision_id": true,
+		"isopen": true, "license_title": true, "license_url": true, "metadata_modified": true,
+		"groups": true, "relationships_as_object": true, "relationships_as_subject": true,
+	}
+	take := func(key string) (interface{}, bool) {
+		value, ok := fields[key]
+		if ok {
+			mapped[key] = true
+		}
+		return value, ok
+	}
+
+	if v, ok := take("name"); ok {
+		md["id"] = text(v)
+	} else {
+		md["id"] = text(fields["id"])
+	}
+	setText(md, "title", take, "title")
+	setText(md, "description", take, "notes")
+	if v, ok := take("organization"); ok {
+		if org, ok := v.(map[string]interface{}); ok {
+			md["organisation"] = text(org["name"])
+		}
+	}
+	if v, ok := take("maintainer_email"); ok && text(v) != "" {
+		md["maintainer"] = text(v)
+		take("maintainer")
+	} else {
+		setText(md, "maintainer", take, "maintainer")
+	}
+	if v, ok := take("author"); ok && text(v) != "" {
+		md["source"] = text(v)
+	}
+	take("author_email")
+	if v, ok := take("metadata_created"); ok {
+		setDate(md, text(v), report)
+	}
+	if v, ok := take("license_id"); ok {
+		setLicense(md, text(v), report)
+	}
+	if v, ok := take("tags"); ok {
+		tags := []string{}
+		for _, tag := range items(v) {
+			if t, ok := tag.(map[string]interface{}); ok {
+				tags = append(tags, text(t["name"]))
+			} else {
+				tags = append(tags, text(tag))
+			}
+		}
+		md["tags"] = tags
+	}
+	if v, ok := take("resources"); ok {
+		for i, res := range items(v) {
+			r, _ := res.(map[string]interface{})
+			appendFormat(md, r["format"], r["mimetype"])
+			if i > 0 {
+				report.Notes = append(report.Notes, fmt.Sprintf("resource %d dropped, only the first endpoint is kept", i))
+				continue
+			}
+			if n := text(r["name"]); n != "" {
+				md["name"] = n
+			}
+			md["endpoint"] = text(r["url"])
+		}
+	}
+	if v, ok := take("extras"); ok {
+		for _, extra := range items(v) {
+			e, _ := extra.(map[string]interface{})
+			key, value := text(e["key"]), text(e["value"])
+			switch key {
+			case "spatial_text", "spatial":
+				md["location"] = value
+			case "frequency", "update_frequency", "accrualPeriodicity":
+				md["updateFrequency"] = value
+			case "methodology":
+				md["methodology"] = value
+			default:
+				report.Unmapped = append(report.Unmapped, "extras."+key)
+			}
+		}
+	}
+
+	for key := range fields {
+		if !mapped[key] {
+			report.Unmapped = append(report.Unmapped, key)
+		}
+	}
+	return md, report
+}
+
+func setText(md map[string]interface{}, field string, take func(string) (interface{}, bool), key string) {
+	if v, ok := take(key); ok {
+		if t := text(v); t != "" {
+			md[field] = t
+		}
+	}
+}
+
+// setDate keeps dates the ledger accepts as RFC3339, completing date-only and zone-less values as UTC
+func setDate(md map[string]interface{}, value string, report *MappingReport) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			md["date"] = t.UTC().Format(time.RFC3339)
+			return
+		}
+	}
+	report.Notes = append(report.Notes, fmt.Sprintf("date %q dropped, not a recognised date", value))
+}
+
+func setLicense(md map[string]interface{}, value string, report *MappingReport) {
+	key := strings.ToLower(value)
+	key = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "https://spdx.org/licenses/"), "http://spdx.org/licenses/"), ".html")
+	if license, ok := importLicenses[key]; ok {
+		md["license"] = license
+		return
+	}
+	if value == "" || key == "notspecified" {
+		return
+	}
+	md["license"] = "other"
+	md["defineLicense"] = value
+	report.Notes = append(report.Notes, fmt.Sprintf("license %q kept as defineLicense", value))
+}
+
+func appendFormat(md map[string]interface{}, values ...interface{}) {
+	for _, v := range values {
+		format := strings.ToLower(text(v))
+		if i := strings.LastIndex(format, "/"); i >= 0 {
+			format = format[i+1:]
+		}
+		if format == "" {
+			continue
+		}
+		types, _ := md["fileTypes"].([]string)
+		for _, t := range types {
+			if t == format {
+				return
+			}
+		}
+		md["fileTypes"] = append(types, format)
+		return
+	}
+}
+
+// text reads a literal, a JSON-LD value object or node reference as a string
+func text(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}:
+		for _, key := range []string{"@value", "@id", "name"} {
+			if s, ok := t[key].(string); ok {
+				return s
+			}
+		}
+		return ""
+	case []interface{}:
+		if len(t) > 0 {
+			return text(t[0])
+		}
+		return ""
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func texts(v interface{}) []string {
+	result := []string{}
+	for _, item := range items(v) {
+		if t := text(item); t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func items(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{v}
+}
+
+func agentName(v interface{}) string {
+	if agent, ok := v.(map[string]interface{}); ok {
+		if name := text(agent["name"]); name != "" {
+			return name
+		}
+	}
+	return text(v)
+}
+
+func contactOf(v interface{}) string {
+	if c, ok := items(v)[0].(map[string]interface{}); ok {
+		if email := text(c["hasEmail"]); email != "" {
+			return strings.TrimPrefix(email, "mailto:")
+		}
+		return text(c["fn"])
+	}
+	return text(v)
+}
+
+func slug(s string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(s, "-"), "-")
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dcatDataset = `{
+	"@context": {"dcat": "http://www.w3.org/ns/dcat#", "dct": "http://purl.org/dc/terms/"},
+	"@type": "dcat:Dataset",
+	"dct:identifier": "org1.example.com/air-quality",
+	"dct:title": "Air quality",
+	"dct:publisher": {"@type": "foaf:Agent", "foaf:name": "org1.example.com"},
+	"dcat:contactPoint": {"@type": "vcard:Kind", "vcard:hasEmail": "mailto:air@org1.example.com"},
+	"dct:issued": "2022-03-01",
+	"dcat:keyword": ["air", "pm10"],
+	"dct:license": "https://spdx.org/licenses/CC-BY-4.0.html",
+	"dct:conformsTo": "https://example.com/profile",
+	"dcat:distribution": [
+		{"@type": "dcat:Distribution", "dcat:accessURL": "https://api.org1.example.com/air", "dct:format": "CSV", "dcat:byteSize": 10},
+		{"@type": "dcat:Distribution", "dcat:mediaType": "application/parquet"}
+	]
+}`
+
+const ckanPackage = `{
+	"success": true,
+	"result": {
+		"id": "7c1e0b9e-0000-4000-8000-000000000000",
+		"name": "road-traffic",
+		"title": "Road traffic",
+		"notes": "Counts per hour",
+		"organization": {"name": "city-council"},
+		"maintainer": "Traffic team",
+		"maintainer_email": "",
+		"metadata_created": "2021-05-04T10:11:12.123456",
+		"license_id": "city-license-1",
+		"tags": [{"name": "traffic"}],
+		"resources": [{"name": "counts.csv", "url": "https://data.example.com/counts.csv", "format": "CSV"}],
+		"extras": [{"key": "frequency", "value": "hourly"}, {"key": "quality", "value": "high"}],
+		"state": "active"
+	}
+}`
+
+func TestImportMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		format    string
+		orgPrefix string
+		want      map[string]interface{}
+		unmapped  []string
+		notes     []string
+		err       string
+	}{
+		{
+			name:   "dcat",
+			doc:    dcatDataset,
+			format: formatDCAT,
+			want: map[string]interface{}{
+				"id":           "org1.example.com/air-quality",
+				"title":        "Air quality",
+				"organisation": "org1.example.com",
+				"maintainer":   "air@org1.example.com",
+				"date":         "2022-03-01T00:00:00Z",
+				"tags":         []interface{}{"air", "pm10"},
+				"license":      "CC-BY-4.0",
+				"endpoint":     "https://api.org1.example.com/air",
+				"fileTypes":    []interface{}{"csv", "parquet"},
+			},
+			unmapped: []string{"conformsTo", "distribution.byteSize"},
+			notes:    []string{"distribution 1 dropped, only the first endpoint is kept"},
+		},
+		{
+			name:   "ckan",
+			doc:    ckanPackage,
+			format: formatCKAN,
+			want: map[string]interface{}{
+				"id":              "city-council/road-traffic",
+				"name":            "counts.csv",
+				"title":           "Road traffic",
+				"description":     "Counts per hour",
+				"organisation":    "city-council",
+				"maintainer":      "Traffic team",
+				"date":            "2021-05-04T10:11:12Z",
+				"license":         "other",
+				"defineLicense":   "city-license-1",
+				"tags":            []interface{}{"traffic"},
+				"endpoint":        "https://data.example.com/counts.csv",
+				"fileTypes":       []interface{}{"csv"},
+				"updateFrequency": "hourly",
+			},
+			unmapped: []string{"extras.quality"},
+			notes:    []string{`license "city-license-1" kept as defineLicense`, `id "road-traffic" rewritten to "city-council/road-traffic"`},
+		},
+		{
+			name:      "ckan with id prefix",
+			doc:       `{"name": "Road Traffic 2021", "metadata_created": "not a date"}`,
+			format:    formatCKAN,
+			orgPrefix: "org2.example.com",
+			want:      map[string]interface{}{"id": "org2.example.com/Road-Traffic-2021"},
+			notes:     []string{`date "not a date" dropped, not a recognised date`, `id "Road Traffic 2021" rewritten to "org2.example.com/Road-Traffic-2021"`},
+		},
+		{name: "no organisation", doc: `{"name": "traffic"}`, format: formatCKAN, err: `dataset "traffic" has no organisation, set --id-prefix`},
+		{name: "no identifier", doc: `{"dct:title": "Untitled"}`, format: formatDCAT, err: "dataset has no identifier"},
+		{name: "unknown format", doc: `{}`, format: "csv", err: `unknown import format "csv"`},
+		{name: "not JSON", doc: `<rdf/>`, format: formatDCAT, err: "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, report, err := importMetadata([]byte(tt.doc), tt.format, tt.orgPrefix)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(bs, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metadata = %v, want %v", got, tt.want)
+			}
+			if strings.Join(report.Unmapped, ", ") != strings.Join(tt.unmapped, ", ") {
+				t.Errorf("unmapped = %v, want %v", report.Unmapped, tt.unmapped)
+			}
+			if strings.Join(report.Notes, "; ") != strings.Join(tt.notes, "; ") {
+				t.Errorf("notes = %v, want %v", report.Notes, tt.notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// deregisterCmd represents the deregister command
+var deregisterCmd = &cobra.Command{
+	Use:   "deregister [id]",
+	Short: "Retire a registered dataset from the ledger",
+	Long: `Remove a dataset from the public ledger, every collection it was published to
+and the implicit collection of the owner. A tombstone recording who retired the
+dataset, when and why is left in its place, and the ID cannot be registered again.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reason, err := cmd.Flags().GetString("reason")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+		_, err = getContract(gw).Submit(
+			"Deregister",
+			client.WithArguments(args[0], reason),
+		)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deregisterCmd)
+
+	deregisterCmd.Flags().String("reason", "", "reason for retiring the dataset")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// defaultChunkSize is the Merkle tree chunk size unless given otherwise
+const defaultChunkSize = 1 << 20
+
+// contentDigest matches the content field of the dataset metadata
+type contentDigest struct {
+	SHA256     string `json:"sha256"`
+	MerkleRoot string `json:"merkleRoot,omitempty"`
+	ChunkSize  int64  `json:"chunkSize,omitempty"`
+	Size       int64  `json:"size"`
+}
+
+// digestFile hashes the whole file, and each chunk of it as leaves of a binary Merkle tree
+// where an odd node is carried up to the next level unchanged
+func digestFile(path string, chunkSize int64) (*contentDigest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	whole := sha256.New()
+	leaves := [][]byte{}
+	buf := make([]byte, chunkSize)
+	var size int64
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			whole.Write(buf[:n])
+			leaf := sha256.Sum256(buf[:n])
+			leaves = append(leaves, leaf[:])
+			size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(leaves) == 0 {
+		leaf := sha256.Sum256(nil)
+		leaves = append(leaves, leaf[:])
+	}
+
+	for len(leaves) > 1 {
+		next := [][]byte{}
+		for i := 0; i < len(leaves); i += 2 {
+			if i+1 == len(leaves) {
+				next = append(next, leaves[i])
+				continue
+			}
+			node := sha256.Sum256(append(append([]byte{}, leaves[i]...), leaves[i+1]...))
+			next = append(next, node[:])
+		}
+		leaves = next
+	}
+
+	return &contentDigest{
+		SHA256:     hex.EncodeToString(whole.Sum(nil)),
+		MerkleRoot: hex.EncodeToString(leaves[0]),
+		ChunkSize:  chunkSize,
+		Size:       size,
+	}, nil
+}
+
+// withContentDigest sets the content field of raw metadata
+func withContentDigest(md []byte, digest *contentDigest) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(md, &fields); err != nil {
+		return nil, err
+	}
+	fields["content"] = digest
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [id...]",
+	Short: "Export registered datasets as DCAT or schema.org JSON-LD",
+	Long: `Print each dataset, as held by the public ledger or the given collection, as
+JSON-LD. With --format dcat the output is a W3C DCAT dcat:Dataset, with
+--format schemaorg a schema.org Dataset. Only the fields disclosed to the
+collection are exported, along with the lineage of the dataset.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		coll, err := cmd.Flags().GetString("collection")
+		cobra.CheckErr(err)
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		for _, key := range args {
+			result, err := getContract(gw).Evaluate(
+				"ExportDataset",
+				client.WithArguments(coll, key, format),
+			)
+			cobra.CheckErr(err)
+
+			var out bytes.Buffer
+			cobra.CheckErr(json.Indent(&out, result, "", "  "))
+			fmt.Println(out.String())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("collection", "c", "", "collection from which to export data")
+	exportCmd.Flags().String("format", "dcat", "export format, dcat or schemaorg")
+}
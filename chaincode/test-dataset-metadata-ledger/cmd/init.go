@@ -4,35 +4,284 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// testNetwork holds the config keys discovered under an organizations/ tree of the test network
+type testNetwork struct {
+	Settings map[string]string
+	// Users and peers as "org1.user1" and "org1.peer0"
+	Users []string
+	Peers []string
+	// Problems found while validating the files
+	Problems []string
+}
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+	Short: "Write a config file for the organisations of a test network",
+	Long: `Discover the organisations, users and peers under the organizations/ tree of a
+Fabric test network and write their keys to the config file, for example:
+
+test-dataset-metadata-ledger init --from-test-network ../../test-network
+
+Every certificate is checked against its private key before anything is written.
+Peer endpoints follow the ports of the test network, 7051 for org1, 9051 for org2
+and so on. A profile named by --profile, "default" otherwise, selects the
+identity, peer, channel and chaincode to use.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+Without --from-test-network the path is asked for. An existing config file is
+only replaced with --force, or updated in place with --merge.`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cobra.CheckErr(viper.SafeWriteConfig())
+		force, err := cmd.Flags().GetBool("force")
+		cobra.CheckErr(err)
+		merge, err := cmd.Flags().GetBool("merge")
+		cobra.CheckErr(err)
+		if force && merge {
+			cobra.CheckErr(fmt.Errorf("--force and --merge cannot be used together"))
+		}
+
+		cfgFile, err := configFilePath()
+		cobra.CheckErr(err)
+		_, err = os.Stat(cfgFile)
+		exists := err == nil
+		if exists && !force && !merge {
+			cobra.CheckErr(fmt.Errorf("config file %s already exists, use --force to replace it or --merge to update it", cfgFile))
+		}
+
+		root, err := cmd.Flags().GetString("from-test-network")
+		cobra.CheckErr(err)
+		if root == "" {
+			root = promptLine(fmt.Sprintf("Path to the test network or its organizations directory [%s]: ", defaultTestNetwork()), defaultTestNetwork())
+		}
+		network, err := discoverTestNetwork(root)
+		cobra.CheckErr(err)
+		if len(network.Problems) > 0 {
+			for _, problem := range network.Problems {
+				fmt.Fprintln(os.Stderr, problem)
+			}
+			cobra.CheckErr(fmt.Errorf("%d problems found under %s, nothing was written", len(network.Problems), root))
+		}
+
+		v := viper.New()
+		v.SetConfigType("yaml")
+		if exists && merge {
+			v.SetConfigFile(cfgFile)
+			cobra.CheckErr(v.ReadInConfig())
+		}
+		for key, value := range network.Settings {
+			v.Set(key, value)
+		}
+
+		// select the identity and peer of the profile, keeping those given explicitly
+		profile := resolveProfile().Value
+		if profile == "" {
+			profile = "default"
+		}
+		user := initSetting("as")
+		if user.Source == sourceDefault && !containsArg(network.Users, user.Value) {
+			user.Value = network.Users[0]
+		}
+		peer := initSetting("peer")
+		if peer.Source == sourceDefault {
+			// prefer a peer of the organisation of the user
+			org := strings.Split(user.Value, ".")[0]
+			if !containsArg(network.Peers, peer.Value) || !strings.HasPrefix(peer.Value, org+".") {
+				peer.Value = network.Peers[0]
+				for _, p := range network.Peers {
+					if strings.HasPrefix(p, org+".") {
+						peer.Value = p
+						break
+					}
+				}
+			}
+		}
+		v.Set("profile", profile)
+		v.Set("profiles."+profile+".as", user.Value)
+		v.Set("profiles."+profile+".peer", peer.Value)
+		v.Set("profiles."+profile+".channel", initSetting("channel").Value)
+		v.Set("profiles."+profile+".chaincode", initSetting("chaincode").Value)
+
+		cobra.CheckErr(os.MkdirAll(filepath.Dir(cfgFile), 0755))
+		cobra.CheckErr(v.WriteConfigAs(cfgFile))
+		fmt.Printf("Wrote %s with users %s and peers %s, profile %q uses %s on %s\n",
+			cfgFile, strings.Join(network.Users, ", "), strings.Join(network.Peers, ", "), profile, user.Value, peer.Value)
 	},
 }
 
-func init() {
-	rootCmd.AddCommand(initCmd)
+// initSetting resolves a setting of the profile being written, which may not be defined yet
+func initSetting(name string) resolvedSetting {
+	setting, err := lookupSetting(name)
+	if err != nil {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		return resolvedSetting{Name: name, Value: flag.DefValue, Source: sourceDefault}
+	}
+
+	return setting
+}
+
+// configFilePath returns the config file read by the CLI, or the one it would read
+func configFilePath() (string, error) {
+	if file := viper.ConfigFileUsed(); file != "" {
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	dir := os.Getenv("CONFIG_PATH")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = home
+	}
+
+	return filepath.Join(dir, ".config-fabric.yaml"), nil
+}
 
-	// Here you will define your flags and configuration settings.
+// defaultTestNetwork is the organizations directory next to the working directory, as in the defaults
+func defaultTestNetwork() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "organizations"
+	}
+	return filepath.Join(wd, "organizations")
+}
+
+// promptLine asks a question on the terminal, answering the default if stdin is not a terminal
+func promptLine(question string, def string) string {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return def
+	}
+
+	fmt.Print(question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return def
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// discoverTestNetwork reads the peer organisations under root, which is either the test network
+// directory or its organizations directory
+func discoverTestNetwork(root string) (*testNetwork, error) {
+	orgsDir := filepath.Join(root, "peerOrganizations")
+	if _, err := os.Stat(orgsDir); err != nil {
+		orgsDir = filepath.Join(root, "organizations", "peerOrganizations")
+	}
+	orgs, err := os.ReadDir(orgsDir)
+	if err != nil {
+		return nil, fmt.Errorf("no peerOrganizations directory under %s: %w", root, err)
+	}
+	orgsDir, err = filepath.Abs(orgsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	network := &testNetwork{Settings: map[string]string{}}
+	for _, org := range orgs {
+		if !org.IsDir() {
+			continue
+		}
+		// e.g. org1.example.com
+		domain := org.Name()
+		orgKey := strings.Split(domain, ".")[0]
+		cryptoPath := filepath.Join(orgsDir, domain)
+		mspID := strings.ToUpper(orgKey[:1]) + orgKey[1:] + "MSP"
+		network.Settings[orgKey+".mspID"] = mspID
+		network.Settings[orgKey+".cryptoPath"] = cryptoPath
+
+		users, _ := os.ReadDir(filepath.Join(cryptoPath, "users"))
+		for _, u := range users {
+			if !u.IsDir() {
+				continue
+			}
+			// e.g. User1@org1.example.com
+			userKey := orgKey + "." + strings.ToLower(strings.Split(u.Name(), "@")[0])
+			mspDir := filepath.Join(cryptoPath, "users", u.Name(), "msp")
+			certs, _ := filepath.Glob(filepath.Join(mspDir, "signcerts", "*.pem"))
+			if len(certs) == 0 {
+				network.Problems = append(network.Problems, fmt.Sprintf("%s: no certificate in %s", userKey, filepath.Join(mspDir, "signcerts")))
+				continue
+			}
+			keyPath := filepath.Join(mspDir, "keystore") + string(filepath.Separator)
+			if _, _, err := (gateway.PEMFiles{MspID: mspID, CertPath: certs[0], KeyPath: keyPath}).Load(); err != nil {
+				network.Problems = append(network.Problems, fmt.Sprintf("%s: %v", userKey, err))
+				continue
+			}
+			network.Settings[userKey+".certPath"] = certs[0]
+			network.Settings[userKey+".keyPath"] = keyPath
+			network.Users = append(network.Users, userKey)
+		}
+
+		peers, _ := os.ReadDir(filepath.Join(cryptoPath, "peers"))
+		for _, p := range peers {
+			if !p.IsDir() {
+				continue
+			}
+			// e.g. peer0.org1.example.com
+			peerKey := orgKey + "." + strings.Split(p.Name(), ".")[0]
+			tlsCertPath := filepath.Join(cryptoPath, "peers", p.Name(), "tls", "ca.crt")
+			if err := checkCertificateFile(tlsCertPath); err != nil {
+				network.Problems = append(network.Problems, fmt.Sprintf("%s: %v", peerKey, err))
+				continue
+			}
+			network.Settings[peerKey+".tlsCertPath"] = tlsCertPath
+			network.Settings[peerKey+".endpoint"] = testNetworkEndpoint(orgKey)
+			network.Settings[peerKey+".gateway"] = p.Name()
+			network.Peers = append(network.Peers, peerKey)
+		}
+	}
+	sort.Strings(network.Users)
+	sort.Strings(network.Peers)
+
+	if len(network.Users) == 0 || len(network.Peers) == 0 {
+		network.Problems = append(network.Problems, fmt.Sprintf("no usable users and peers found in %s", orgsDir))
+	}
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// initCmd.PersistentFlags().String("foo", "", "A help for foo")
+	return network, nil
+}
+
+// testNetworkEndpoint returns the peer endpoint of the test network for an org, e.g. localhost:9051 for org2
+func testNetworkEndpoint(orgKey string) string {
+	var n int
+	if _, err := fmt.Sscanf(orgKey, "org%d", &n); err != nil || n < 1 {
+		return "localhost:7051"
+	}
+	return fmt.Sprintf("localhost:%d", 7051+2000*(n-1))
+}
+
+func checkCertificateFile(path string) error {
+	certificatePEM, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := identity.CertificateFromPEM(certificatePEM); err != nil {
+		return fmt.Errorf("invalid certificate %s: %w", path, err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// initCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	initCmd.Flags().String("from-test-network", "", "path to the test network or its organizations directory, asked for if empty")
+	initCmd.Flags().Bool("force", false, "replace an existing config file")
+	initCmd.Flags().Bool("merge", false, "update an existing config file, keeping the keys not discovered")
 }
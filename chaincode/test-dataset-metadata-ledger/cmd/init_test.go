@@ -0,0 +1,210 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// newTestPEM creates a self-signed certificate and its private key, PEM encoded
+func newTestPEM(t *testing.T) (certificatePEM []byte, privateKeyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user1"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeTestFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestNetwork lays out the organizations/ tree of a test network with a user and a peer per
+// organisation, and returns the test network directory
+func newTestNetwork(t *testing.T, orgs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, org := range orgs {
+		domain := org + ".example.com"
+		orgDir := filepath.Join(root, "organizations", "peerOrganizations", domain)
+		certificatePEM, privateKeyPEM := newTestPEM(t)
+		mspDir := filepath.Join(orgDir, "users", "User1@"+domain, "msp")
+		writeTestFile(t, filepath.Join(mspDir, "signcerts", "cert.pem"), certificatePEM)
+		writeTestFile(t, filepath.Join(mspDir, "keystore", "priv_sk"), privateKeyPEM)
+		caPEM, _ := newTestPEM(t)
+		writeTestFile(t, filepath.Join(orgDir, "peers", "peer0."+domain, "tls", "ca.crt"), caPEM)
+	}
+	return root
+}
+
+func userMSPDir(root string, org string) string {
+	domain := org + ".example.com"
+	return filepath.Join(root, "organizations", "peerOrganizations", domain, "users", "User1@"+domain, "msp")
+}
+
+func TestDiscoverTestNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, root string)
+		problem string
+	}{
+		{name: "valid"},
+		{name: "missing key", prepare: func(t *testing.T, root string) {
+			if err := os.Remove(filepath.Join(userMSPDir(root, "org1"), "keystore", "priv_sk")); err != nil {
+				t.Fatal(err)
+			}
+		}, problem: "org1.user1: failed to load identity from " + "%s" + ": no private key found"},
+		{name: "certificate and key mismatch", prepare: func(t *testing.T, root string) {
+			_, otherKeyPEM := newTestPEM(t)
+			writeTestFile(t, filepath.Join(userMSPDir(root, "org1"), "keystore", "priv_sk"), otherKeyPEM)
+		}, problem: "org1.user1: failed to load identity from " + "%s" + ": no private key matches the certificate"},
+		{name: "missing certificate", prepare: func(t *testing.T, root string) {
+			if err := os.Remove(filepath.Join(userMSPDir(root, "org1"), "signcerts", "cert.pem")); err != nil {
+				t.Fatal(err)
+			}
+		}, problem: "org1.user1: no certificate in"},
+		{name: "invalid TLS certificate", prepare: func(t *testing.T, root string) {
+			writeTestFile(t, filepath.Join(root, "organizations", "peerOrganizations", "org2.example.com", "peers", "peer0.org2.example.com", "tls", "ca.crt"), []byte("not a certificate"))
+		}, problem: "org2.peer0: invalid certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestNetwork(t, "org1", "org2")
+			if tt.prepare != nil {
+				tt.prepare(t, root)
+			}
+
+			network, err := discoverTestNetwork(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.problem != "" {
+				keystore := filepath.Join(userMSPDir(root, "org1"), "keystore") + string(filepath.Separator)
+				want := strings.Replace(tt.problem, "%s", keystore, 1)
+				if len(network.Problems) != 1 || !strings.HasPrefix(network.Problems[0], want) {
+					t.Errorf("problems = %q, want %q", network.Problems, want)
+				}
+				return
+			}
+
+			if len(network.Problems) != 0 {
+				t.Fatalf("problems = %q", network.Problems)
+			}
+			if got := strings.Join(network.Users, ","); got != "org1.user1,org2.user1" {
+				t.Errorf("users = %s", got)
+			}
+			if got := strings.Join(network.Peers, ","); got != "org1.peer0,org2.peer0" {
+				t.Errorf("peers = %s", got)
+			}
+			for key, want := range map[string]string{
+				"org2.mspID":             "Org2MSP",
+				"org2.peer0.endpoint":    "localhost:9051",
+				"org2.peer0.gateway":     "peer0.org2.example.com",
+				"org1.user1.certPath":    filepath.Join(userMSPDir(root, "org1"), "signcerts", "cert.pem"),
+				"org1.user1.keyPath":     filepath.Join(userMSPDir(root, "org1"), "keystore") + string(filepath.Separator),
+				"org1.peer0.tlsCertPath": filepath.Join(root, "organizations", "peerOrganizations", "org1.example.com", "peers", "peer0.org1.example.com", "tls", "ca.crt"),
+			} {
+				if got := network.Settings[key]; got != want {
+					t.Errorf("%s = %s, want %s", key, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("organizations directory", func(t *testing.T) {
+		root := newTestNetwork(t, "org1")
+		network, err := discoverTestNetwork(filepath.Join(root, "organizations"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(network.Problems) != 0 || len(network.Users) != 1 {
+			t.Errorf("network = %+v", network)
+		}
+	})
+
+	t.Run("no organizations", func(t *testing.T) {
+		if _, err := discoverTestNetwork(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no peerOrganizations directory") {
+			t.Errorf("error = %v", err)
+		}
+	})
+
+	t.Run("no peers", func(t *testing.T) {
+		root := newTestNetwork(t, "org1")
+		if err := os.RemoveAll(filepath.Join(root, "organizations", "peerOrganizations", "org1.example.com", "peers")); err != nil {
+			t.Fatal(err)
+		}
+		network, err := discoverTestNetwork(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(network.Problems) != 1 || !strings.HasPrefix(network.Problems[0], "no usable users and peers found") {
+			t.Errorf("problems = %q", network.Problems)
+		}
+	})
+}
+
+func TestInitWritesProfile(t *testing.T) {
+	withSettings(t, "", nil, map[string]string{"channel": "datasets"})
+	root := newTestNetwork(t, "org1", "org2")
+	cfgDir := t.TempDir()
+	t.Setenv("CONFIG_PATH", cfgDir)
+	if err := initCmd.Flags().Set("from-test-network", root); err != nil {
+		t.Fatal(err)
+	}
+	defer initCmd.Flags().Set("from-test-network", "")
+
+	initCmd.Run(initCmd, nil)
+
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(cfgDir, ".config-fabric.yaml"))
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"profile":                    "default",
+		"profiles.default.as":        "org1.user1",
+		"profiles.default.peer":      "org1.peer0",
+		"profiles.default.channel":   "datasets",
+		"profiles.default.chaincode": "basic",
+		"org2.peer0.endpoint":        "localhost:9051",
+	} {
+		if got := v.GetString(key); got != want {
+			t.Errorf("%s = %s, want %s", key, got, want)
+		}
+	}
+}
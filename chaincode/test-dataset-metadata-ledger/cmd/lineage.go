@@ -0,0 +1,73 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// lineageCmd represents the lineage command
+var lineageCmd = &cobra.Command{
+	Use:   "lineage",
+	Short: "Record and query relations between datasets",
+	Long: `Manage the lineage graph of datasets.
+
+A dataset of your organisation can be related to any dataset known on the
+public ledger by its ID, with one of the relations derivedFrom, supersedes
+or partOf. The lineage graph is public, so both datasets must have been
+registered to the public ledger; datasets shared only through private or
+named collections cannot be related.`,
+}
+
+var lineageAddCmd = &cobra.Command{
+	Use:   "add [from-id] [relation] [to-id]",
+	Short: "Relate a dataset of your organisation to another dataset",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		transformation, err := cmd.Flags().GetString("transformation")
+		cobra.CheckErr(err)
+
+		submitAccess("AddLineage", nil, args[0], args[2], args[1], transformation)
+	},
+}
+
+var lineageRemoveCmd = &cobra.Command{
+	Use:   "remove [from-id] [relation] [to-id]",
+	Short: "Remove a relation of a dataset of your organisation",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		submitAccess("RemoveLineage", nil, args[0], args[2], args[1])
+	},
+}
+
+var lineageShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Show the datasets a dataset stems from",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		depth, err := cmd.Flags().GetInt("depth")
+		cobra.CheckErr(err)
+
+		evaluateAccess("GetLineage", args[0], strconv.Itoa(depth))
+	},
+}
+
+var lineageDescendantsCmd = &cobra.Command{
+	Use:   "descendants [id]",
+	Short: "Show the datasets that stem from a dataset",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("GetDescendants", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lineageCmd)
+	lineageCmd.AddCommand(lineageAddCmd, lineageRemoveCmd, lineageShowCmd, lineageDescendantsCmd)
+
+	lineageAddCmd.Flags().String("transformation", "", "how the dataset was obtained from the other one")
+	lineageShowCmd.Flags().Int("depth", 0, "number of hops to follow, all if 0")
+}
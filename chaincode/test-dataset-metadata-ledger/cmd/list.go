@@ -0,0 +1,63 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List metadata by key range",
+	Long: `List the datasets whose IDs fall in [start, end) on the public ledger or in a
+named collection, one page at a time. For example:
+
+test-dataset-metadata-ledger list --start org1.example.com/ --end org1.example.com0 --page-size 10
+
+Each record carries its key. The result carries a bookmark, pass it with
+--bookmark to fetch the next page, an empty bookmark means the range is
+exhausted. The page size is capped by the chaincode.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		coll, err := cmd.Flags().GetString("collection")
+		cobra.CheckErr(err)
+		start, err := cmd.Flags().GetString("start")
+		cobra.CheckErr(err)
+		end, err := cmd.Flags().GetString("end")
+		cobra.CheckErr(err)
+		pageSize, err := cmd.Flags().GetInt("page-size")
+		cobra.CheckErr(err)
+		bookmark, err := cmd.Flags().GetString("bookmark")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		result, err := getContract(gw).Evaluate(
+			"QueryByRange",
+			client.WithArguments(coll, start, end, strconv.Itoa(pageSize), bookmark),
+		)
+		cobra.CheckErr(err)
+		fmt.Printf("Result: %s\n", string(result))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().StringP("collection", "c", "", "collection in which to list data")
+	listCmd.Flags().String("start", "", "first key of the range, inclusive")
+	listCmd.Flags().String("end", "", "last key of the range, exclusive, open if empty")
+	listCmd.Flags().Int("page-size", 10, "number of records per page")
+	listCmd.Flags().String("bookmark", "", "bookmark returned by the previous page")
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// signCmd represents the sign command
+var signCmd = &cobra.Command{
+	Use:   "sign [artifact...]",
+	Short: "Sign prepared proposals, transactions and commits with a local key",
+	Long: `Sign artifacts written by "register --prepare" and "submit --signed" with the key
+of the identity given by --as, without connecting to the network. Each artifact
+is described before it is signed and is only signed by the identity that
+created it. The signature is written into the artifact unless --out is given.
+
+A transaction signed offline goes through three rounds of signing:
+
+test-dataset-metadata-ledger register --metadata md.json --prepare tx.proposal.json
+test-dataset-metadata-ledger sign tx.proposal.json          # offline
+test-dataset-metadata-ledger submit --signed tx.proposal.json
+test-dataset-metadata-ledger sign <txid>.transaction.json   # offline
+test-dataset-metadata-ledger submit --signed <txid>.transaction.json
+test-dataset-metadata-ledger sign <txid>.commit.json        # offline
+test-dataset-metadata-ledger submit --signed <txid>.commit.json
+
+Proposals carry the transient data of the transaction, such as the private
+metadata, so artifacts are written readable by their owner only.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := cmd.Flags().GetString("out")
+		cobra.CheckErr(err)
+		if out != "" && len(args) > 1 {
+			cobra.CheckErr(fmt.Errorf("--out only applies to a single artifact"))
+		}
+
+		id, sign, err := getGatewayConfig().LoadIdentity()
+		cobra.CheckErr(err)
+		if sign == nil {
+			cobra.CheckErr(fmt.Errorf("identity %s has no private key to sign with", resolveSetting("as").Value))
+		}
+
+		for _, path := range args {
+			artifact, err := gateway.ReadArtifact(path)
+			cobra.CheckErr(err)
+			fmt.Printf("Signing %s %s on channel %s", artifact.Kind, artifact.TransactionID, artifact.Channel)
+			if artifact.Function != "" {
+				fmt.Printf(", %s of chaincode %s", artifact.Function, artifact.Chaincode)
+			}
+			fmt.Println()
+
+			cobra.CheckErr(artifact.Sign(id, sign))
+			target := path
+			if out != "" {
+				target = out
+			}
+			cobra.CheckErr(artifact.Write(target))
+			fmt.Printf("Wrote signed %s to %s\n", artifact.Kind, target)
+		}
+	},
+}
+
+// submitCmd represents the submit command
+var submitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Send a proposal, transaction or commit signed offline",
+	Long: `Send a signed artifact to the network with the certificate of the identity given
+by --as, the private key is not needed. A signed proposal is endorsed and gives
+the transaction to sign next, a signed transaction is submitted to the orderer
+and gives the commit to sign next, and a signed commit waits for the commit
+status of the transaction. See "sign" for the whole flow.`,
+	Args: cobra.NoArgs,
+	// errors of the network are not usage errors
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := cmd.Flags().GetString("signed")
+		if err != nil {
+			return err
+		}
+		out, err := cmd.Flags().GetString("out")
+		if err != nil {
+			return err
+		}
+
+		artifact, err := gateway.ReadArtifact(path)
+		if err != nil {
+			return err
+		}
+		if len(artifact.Signature) == 0 {
+			return fmt.Errorf("%s %s is not signed, sign it with \"sign %s\" first", artifact.Kind, artifact.TransactionID, path)
+		}
+
+		gw := gateway.NewFabricGateway()
+		if err := gw.WithConfiguration(offlineGatewayConfig(getGatewayConfig())); err != nil {
+			return withExitCode(err)
+		}
+		defer gw.Close()
+
+		var next *gateway.Artifact
+		switch artifact.Kind {
+		case gateway.ArtifactProposal:
+			proposal, err := gw.Gateway.NewSignedProposal(artifact.Bytes, artifact.Signature)
+			if err != nil {
+				return err
+			}
+			transaction, err := proposal.Endorse()
+			if err != nil {
+				return withExitCode(err)
+			}
+			if result := transaction.Result(); len(result) > 0 {
+				fmt.Printf("Result: %s\n", string(result))
+			}
+			bs, err := transaction.Bytes()
+			if err != nil {
+				return err
+			}
+			if next, err = gateway.NewArtifact(gateway.ArtifactTransaction, bs); err != nil {
+				return err
+			}
+		case gateway.ArtifactTransaction:
+			transaction, err := gw.Gateway.NewSignedTransaction(artifact.Bytes, artifact.Signature)
+			if err != nil {
+				return err
+			}
+			commit, err := transaction.Submit()
+			if err != nil {
+				return withExitCode(err)
+			}
+			bs, err := commit.Bytes()
+			if err != nil {
+				return err
+			}
+			if next, err = gateway.NewArtifact(gateway.ArtifactCommit, bs); err != nil {
+				return err
+			}
+		case gateway.ArtifactCommit:
+			commit, err := gw.Gateway.NewSignedCommit(artifact.Bytes, artifact.Signature)
+			if err != nil {
+				return err
+			}
+			status, err := commit.Status()
+			if err != nil {
+				return withExitCode(err)
+			}
+			if !status.Successful {
+				return fmt.Errorf("transaction %s failed to commit with status %d", status.TransactionID, int32(status.Code))
+			}
+			fmt.Printf("Transaction %s committed in block %d\n", status.TransactionID, status.BlockNumber)
+			return nil
+		}
+
+		if out == "" {
+			out = filepath.Join(filepath.Dir(path), next.TransactionID+"."+next.Kind+".json")
+		}
+		if err := next.Write(out); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s to %s, sign it with \"sign %s\"\n", next.Kind, out, out)
+
+		return nil
+	},
+}
+
+// offlineGatewayConfig connects with the certificate of the identity only, leaving signing to "sign"
+func offlineGatewayConfig(config gateway.FabricGatewayConfiguration) gateway.FabricGatewayConfiguration {
+	config.Identity = gateway.Certificate{MspID: config.MspID, CertPath: config.CertPath}
+	return config
+}
+
+// prepareProposal writes the unsigned proposal of a transaction to a file
+func prepareProposal(contract *client.Contract, path string, name string, options ...client.ProposalOption) error {
+	proposal, err := contract.NewProposal(name, options...)
+	if err != nil {
+		return err
+	}
+	bs, err := proposal.Bytes()
+	if err != nil {
+		return err
+	}
+	artifact, err := gateway.NewArtifact(gateway.ArtifactProposal, bs)
+	if err != nil {
+		return err
+	}
+	if err := artifact.Write(path); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote proposal %s to %s, sign it with \"sign %s\"\n", artifact.TransactionID, path, path)
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd, submitCmd)
+
+	signCmd.Flags().String("out", "", "file to write the signed artifact to, the artifact itself if empty")
+	submitCmd.Flags().String("signed", "", "signed proposal, transaction or commit to send")
+	submitCmd.Flags().String("out", "", "file to write the next artifact to, <txid>.<kind>.json next to the signed one if empty")
+	cobra.CheckErr(submitCmd.MarkFlagRequired("signed"))
+}
@@ -0,0 +1,276 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	gatewaypb "github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// exit codes telling a missing dataset apart from an unreachable network, other errors exit with 1
+const (
+	exitNotFound  = 2
+	exitTransport = 3
+)
+
+var outputFormats = []string{"json", "yaml", "table", "csv", "ndjson"}
+
+// notFoundCode prefixes the chaincode message of a dataset that cannot be read because it is missing,
+// retired or expired
+const notFoundCode = "DATASET_NOT_FOUND: "
+
+// addOutputFlags adds the --output and --fields flags to a command printing records
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "json", "output format, one of "+strings.Join(outputFormats, ", "))
+	cmd.Flags().StringSlice("fields", []string{}, "fields of the records to print, all fields if empty")
+}
+
+// outputFromFlags returns the output format and fields of a command
+func outputFromFlags(cmd *cobra.Command) (string, []string, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", nil, err
+	}
+	if !containsArg(outputFormats, format) {
+		return "", nil, fmt.Errorf("--output must be one of %s, got %q", strings.Join(outputFormats, ", "), format)
+	}
+	fields, err := cmd.Flags().GetStringSlice("fields")
+	if err != nil {
+		return "", nil, err
+	}
+
+	return format, fields, nil
+}
+
+// decodeRecords decodes a JSON object or array of objects returned by the chaincode
+func decodeRecords(result []byte) ([]map[string]interface{}, error) {
+	result = bytes.TrimSpace(result)
+	if len(result) > 0 && result[0] == '[' {
+		var records []map[string]interface{}
+		if err := json.Unmarshal(result, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return records, nil
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(result, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return []map[string]interface{}{record}, nil
+}
+
+// recordColumns returns the given fields, or else the fields of all records with "id" first
+func recordColumns(records []map[string]interface{}, fields []string) []string {
+	if len(fields) > 0 {
+		return fields
+	}
+
+	columns := []string{}
+	for _, record := range records {
+		for name := range record {
+			if name != "id" && !containsArg(columns, name) {
+				columns = append(columns, name)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	return append([]string{"id"}, columns...)
+}
+
+// selectFields keeps the given fields of each record, all of them if fields is empty
+func selectFields(records []map[string]interface{}, fields []string) []map[string]interface{} {
+	if len(fields) == 0 {
+		return records
+	}
+
+	selected := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		selected[i] = map[string]interface{}{}
+		for _, field := range fields {
+			if value, ok := record[field]; ok {
+				selected[i][field] = value
+			}
+		}
+	}
+
+	return selected
+}
+
+// cellText renders a value in a table or CSV cell, nested values as compact JSON
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	bs, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(bs)
+}
+
+// writeRecords prints the records in the format. With asArray unset a single record is printed as
+// an object, otherwise the records are printed as one array.
+func writeRecords(w io.Writer, records []map[string]interface{}, asArray bool, format string, fields []string) error {
+	records = selectFields(records, fields)
+	var value interface{} = records
+	if !asArray && len(records) == 1 {
+		value = records[0]
+	}
+
+	switch format {
+	case "json":
+		bs, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(bs))
+		return err
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "table":
+		columns := recordColumns(records, fields)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+		for _, record := range records {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = strings.ReplaceAll(cellText(record[column]), "\t", " ")
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	case "csv":
+		columns := recordColumns(records, fields)
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		for _, record := range records {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = cellText(record[column])
+			}
+			if err := cw.Write(cells); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// errorMessages returns the message of an error and of the peers behind it
+func errorMessages(err error) []string {
+	messages := []string{err.Error()}
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*gatewaypb.ErrorDetail); ok {
+			messages = append(messages, d.Message)
+		}
+	}
+
+	return messages
+}
+
+// isNotFound reports whether the chaincode rejected a query because the dataset cannot be read
+func isNotFound(err error) bool {
+	for _, message := range errorMessages(err) {
+		if strings.Contains(message, notFoundCode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTransport reports whether the error comes from reaching the network rather than from the chaincode
+func isTransport(err error) bool {
+	var connectionErr *gateway.ConnectionError
+	if errors.As(err, &connectionErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+
+	return false
+}
+
+// exitError carries the exit code of a failed command up to main
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode gives the error the exit code of its kind, nil stays nil
+func withExitCode(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isTransport(err):
+		return &exitError{code: exitTransport, err: err}
+	case isNotFound(err):
+		return &exitError{code: exitNotFound, err: err}
+	}
+
+	return err
+}
+
+// exitCode returns the code the process exits with after the error of a command
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+
+	return 1
+}
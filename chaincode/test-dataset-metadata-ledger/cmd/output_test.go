@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	gatewaypb "github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// peerError is the error of an evaluation rejected by the chaincode with the message
+func peerError(t *testing.T, message string) error {
+	t.Helper()
+	st, err := status.New(codes.Unknown, "evaluate call to endorser returned error").
+		WithDetails(&gatewaypb.ErrorDetail{Address: "peer0.org1.example.com:7051", MspId: "Org1MSP", Message: message})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return st.Err()
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", want: 0},
+		{name: "not found", err: peerError(t, `chaincode response 500, DATASET_NOT_FOUND: Dataset "org1.example.com/x" does not exist in collection "".`), want: exitNotFound},
+		{name: "other chaincode error", err: peerError(t, `chaincode response 500, Access request "r1" for dataset "org1.example.com/x" does not exist.`), want: 1},
+		{name: "unavailable", err: status.Error(codes.Unavailable, "connection refused"), want: exitTransport},
+		{name: "connection", err: &gateway.ConnectionError{Endpoint: "localhost:7051", Err: errors.New("refused")}, want: exitTransport},
+		{name: "configuration", err: &gateway.ConfigError{Setting: "TLS CA certificate", Value: "ca.crt", Err: errors.New("missing")}, want: 1},
+		{name: "wrapped", err: fmt.Errorf("query: %w", withExitCode(status.Error(codes.DeadlineExceeded, "timeout"))), want: exitTransport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(withExitCode(tt.err)); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
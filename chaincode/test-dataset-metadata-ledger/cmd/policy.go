@@ -0,0 +1,68 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// policyCmd represents the policy command
+var policyCmd = &cobra.Command{
+	Use:   "policy [operation...]",
+	Short: "Show or set access policies of ledger operations",
+	Long: `Show the effective access policy of each operation given as argument,
+e.g. "Register" or "QueryPrivate". Every organisation decides which of its own
+clients may call an operation, --org selects the organisation whose policies are
+shown and defaults to your own.
+
+With --set, the policy read from the given file replaces the one your
+organisation stored for its operation. This requires an admin identity by
+default.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		policyPath, err := cmd.Flags().GetString("set")
+		cobra.CheckErr(err)
+		mspID, err := cmd.Flags().GetString("org")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		if mspID == "" {
+			mspID = gatewayConfig.MspID
+		}
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		if policyPath != "" {
+			policy, err := os.ReadFile(policyPath)
+			cobra.CheckErr(err)
+			_, err = getContract(gw).Submit(
+				"SetPolicy",
+				client.WithArguments(string(policy)),
+			)
+			cobra.CheckErr(err)
+		}
+
+		for _, operation := range args {
+			result, err := getContract(gw).Evaluate(
+				"GetPolicy",
+				client.WithArguments(mspID, operation),
+			)
+			cobra.CheckErr(err)
+			fmt.Printf("Result: %s\n", string(result))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+
+	policyCmd.Flags().String("set", "", "path to policy file to store on the ledger")
+	policyCmd.Flags().String("org", "", "MSP ID of the organisation whose policies to show, defaults to your own")
+}
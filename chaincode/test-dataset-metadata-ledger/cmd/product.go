@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// productCmd represents the product command
+var productCmd = &cobra.Command{
+	Use:   "product",
+	Short: "Bundle registered datasets into data products",
+	Long: `A data product groups related datasets in order, under metadata they share,
+for example:
+
+{"id": "org1.example.com/census", "title": "Census 2021", "organisation": "Org1",
+ "fieldNames": ["region", "population"],
+ "members": [{"id": "org1.example.com/census-2021-a", "collection": ""}]}
+
+Every member must exist in its collection, where "" is the public ledger, and
+be visible to your organisation. Members held in collections are kept in the
+implicit collection of your organisation, so only your organisation sees them.`,
+}
+
+var productCreateCmd = &cobra.Command{
+	Use:   "create [product-file]",
+	Short: "Create a data product of your organisation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		product, err := os.ReadFile(args[0])
+		cobra.CheckErr(err)
+
+		submitAccess("CreateDataProduct", nil, string(product))
+	},
+}
+
+var productAddCmd = &cobra.Command{
+	Use:   "add [product-id] [dataset-id]",
+	Short: "Add a dataset to a data product",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		collection, err := cmd.Flags().GetString("collection")
+		cobra.CheckErr(err)
+		position, err := cmd.Flags().GetInt("position")
+		cobra.CheckErr(err)
+
+		submitAccess("AddToDataProduct", nil, args[0], args[1], collection, strconv.Itoa(position))
+	},
+}
+
+var productRemoveCmd = &cobra.Command{
+	Use:   "remove [product-id] [dataset-id]",
+	Short: "Remove a dataset from a data product",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		submitAccess("RemoveFromDataProduct", nil, args[0], args[1])
+	},
+}
+
+var productShowCmd = &cobra.Command{
+	Use:   "show [product-id]",
+	Short: "Show a data product and its members in order",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("GetDataProduct", args[0])
+	},
+}
+
+var productListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the data products on the ledger",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		owner, err := cmd.Flags().GetString("owner")
+		cobra.CheckErr(err)
+
+		evaluateAccess("ListDataProducts", owner)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(productCmd)
+	productCmd.AddCommand(productCreateCmd, productAddCmd, productRemoveCmd, productShowCmd, productListCmd)
+
+	productAddCmd.Flags().StringP("collection", "c", "", "collection the dataset is read from, the public ledger if empty")
+	productAddCmd.Flags().Int("position", -1, "position of the dataset in the product, appended if negative")
+	productListCmd.Flags().String("owner", "", "only list data products of this MSP ID")
+}
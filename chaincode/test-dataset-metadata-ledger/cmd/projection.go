@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// projectionCmd represents the projection command
+var projectionCmd = &cobra.Command{
+	Use:   "projection [id...]",
+	Short: "Show which metadata fields each collection holds for a dataset",
+	Long: `Show the effective projection of each dataset given as argument, that is the
+fields written to every collection it was registered in. Only datasets of your
+own organisation can be inspected.
+
+With --set-policy, the disclosure policy read from the given file replaces the
+one of your organisation first. For example:
+
+{"default": ["title", "organisation", "license"], "collections": {"": ["title"]}}
+
+The policy applies to datasets registered or updated afterwards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		policyPath, err := cmd.Flags().GetString("set-policy")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		if policyPath != "" {
+			policy, err := os.ReadFile(policyPath)
+			cobra.CheckErr(err)
+			_, err = getContract(gw).Submit(
+				"SetDisclosurePolicy",
+				client.WithArguments(string(policy)),
+			)
+			cobra.CheckErr(err)
+		}
+
+		for _, key := range args {
+			result, err := getContract(gw).Evaluate(
+				"QueryProjection",
+				client.WithArguments(key),
+			)
+			cobra.CheckErr(err)
+			fmt.Printf("Result: %s\n", string(result))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(projectionCmd)
+
+	projectionCmd.Flags().String("set-policy", "", "path to disclosure policy file to store on the ledger")
+}
@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
@@ -13,46 +15,123 @@ import (
 
 // queryCmd represents the query command
 var queryCmd = &cobra.Command{
-	Use:   "query",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		privateMode, err := cmd.Flags().GetBool("private")
+	Use:   "query [key...]",
+	Short: "Query metadata by key",
+	Long: `Query the metadata of one or more datasets from the public ledger, a named
+collection or, with --private, the implicit collection of your organisation.
+For example:
+
+test-dataset-metadata-ledger query org1.example.com/data001 -o table --fields id,title
+
+A single key prints one record, several keys print one array. --output selects
+json (default), yaml, table, csv or ndjson, and --fields the fields to print.
 
+The command exits with 2 if a dataset is not found, with 3 if the network
+cannot be reached and with 1 on any other error.`,
+	Args: queryArgs,
+	// errors of the query are not usage errors
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		privateMode, err := cmd.Flags().GetBool("private")
+		if err != nil {
+			return err
+		}
 		coll, err := cmd.Flags().GetString("collection")
-		cobra.CheckErr(err)
+		if err != nil {
+			return err
+		}
+		format, fields, err := outputFromFlags(cmd)
+		if err != nil {
+			return err
+		}
 
 		gatewayConfig := getGatewayConfig()
 		gw := gateway.NewFabricGateway()
-		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
-		defer gw.Client.Close()
-		defer gw.Gateway.Close()
+		if err := gw.WithConfiguration(gatewayConfig); err != nil {
+			return withExitCode(err)
+		}
+		defer gw.Close()
+
+		// find datasets by column name instead of by key
+		field, err := cmd.Flags().GetString("field")
+		if err != nil {
+			return err
+		}
+		if field != "" {
+			max, err := cmd.Flags().GetInt("max")
+			if err != nil {
+				return err
+			}
+			result, err := getContract(gw).Evaluate(
+				"QueryByField",
+				client.WithArguments(coll, field, strconv.Itoa(max)),
+			)
+			if err != nil {
+				return withExitCode(err)
+			}
+			records, err := decodeRecords(result)
+			if err != nil {
+				return err
+			}
+			return writeRecords(os.Stdout, records, true, format, fields)
+		}
 
+		// print the datasets found as one document and report the missing ones
+		records := []map[string]interface{}{}
+		missing := 0
 		for _, key := range args {
+			var result []byte
 			if privateMode {
-				result, err := gw.GetContract("basic", "mychannel").Evaluate(
+				result, err = getContract(gw).Evaluate(
 					"QueryPrivate",
 					client.WithArguments(key),
 				)
-				cobra.CheckErr(err)
-				fmt.Printf("Result: %s\n", string(result))
 			} else {
-				result, err := gw.GetContract("basic", "mychannel").Evaluate(
+				result, err = getContract(gw).Evaluate(
 					"Query",
 					client.WithArguments(coll, key),
 				)
-				cobra.CheckErr(err)
-				fmt.Printf("Result: %s\n", string(result))
 			}
+			if err != nil && isNotFound(err) && !isTransport(err) {
+				fmt.Fprintf(os.Stderr, "Not found: %s\n", key)
+				missing++
+				continue
+			}
+			if err != nil {
+				return withExitCode(err)
+			}
+			found, err := decodeRecords(result)
+			if err != nil {
+				return err
+			}
+			records = append(records, found...)
+		}
+
+		if len(records) > 0 {
+			if err := writeRecords(os.Stdout, records, len(args) > 1, format, fields); err != nil {
+				return err
+			}
+		}
+		if missing > 0 {
+			return &exitError{code: exitNotFound, err: fmt.Errorf("%d of %d datasets not found", missing, len(args))}
 		}
+
+		return nil
 	},
 }
 
+// queryArgs requires at least one key, or none when datasets are found by --field
+func queryArgs(cmd *cobra.Command, args []string) error {
+	field, err := cmd.Flags().GetString("field")
+	if err != nil {
+		return err
+	}
+	if field != "" {
+		return cobra.NoArgs(cmd, args)
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
 func init() {
 	rootCmd.AddCommand(queryCmd)
 
@@ -66,4 +145,7 @@ func init() {
 	// is called directly, e.g.:
 	queryCmd.Flags().StringP("collection", "c", "", "collection in which to query data")
 	queryCmd.Flags().BoolP("private", "p", false, "query private collection")
+	queryCmd.Flags().String("field", "", "find datasets with a column of this name")
+	queryCmd.Flags().Int("max", 50, "maximum number of datasets found by --field")
+	addOutputFlags(queryCmd)
 }
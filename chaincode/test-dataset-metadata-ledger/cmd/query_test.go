@@ -0,0 +1,36 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"testing"
+)
+
+func TestQueryArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		args  []string
+		err   bool
+	}{
+		{name: "no key", err: true},
+		{name: "one key", args: []string{"org1.example.com/data001"}},
+		{name: "several keys", args: []string{"org1.example.com/data001", "org1.example.com/data002"}},
+		{name: "field", field: "region"},
+		{name: "field and key", field: "region", args: []string{"org1.example.com/data001"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := queryCmd.Flags().Set("field", tt.field); err != nil {
+				t.Fatal(err)
+			}
+			defer queryCmd.Flags().Set("field", "")
+
+			err := queryCmd.Args(queryCmd, tt.args)
+			if (err != nil) != tt.err {
+				t.Errorf("Args(%v) = %v, want error %v", tt.args, err, tt.err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
@@ -26,9 +27,62 @@ to quickly create a Cobra application.`,
 		// read metadata and encode to base64
 		mdPath, err := cmd.Flags().GetString("metadata")
 		cobra.CheckErr(err)
-		md, err := os.ReadFile(mdPath)
+		docs, err := readMetadataDocuments(mdPath)
 		cobra.CheckErr(err)
 
+		// convert DCAT or CKAN input and report what could not be mapped
+		format, err := cmd.Flags().GetString("format")
+		cobra.CheckErr(err)
+		if format != formatLedger {
+			idPrefix, err := cmd.Flags().GetString("id-prefix")
+			cobra.CheckErr(err)
+			for i, doc := range docs {
+				md, report, err := importMetadata(doc, format, idPrefix)
+				cobra.CheckErr(err)
+				fmt.Fprintln(os.Stderr, report)
+				docs[i] = md
+			}
+		}
+		info, err := os.Stat(mdPath)
+		cobra.CheckErr(err)
+		batch := info.IsDir() || len(docs) != 1
+		var md []byte
+		if !batch {
+			md = docs[0]
+		}
+
+		// attach the digest of the dataset content if given
+		contentPath, err := cmd.Flags().GetString("content-file")
+		cobra.CheckErr(err)
+		if contentPath != "" && batch {
+			cobra.CheckErr(fmt.Errorf("--content-file only applies to a single metadata document"))
+		}
+		if contentPath != "" {
+			chunkSize, err := cmd.Flags().GetInt64("chunk-size")
+			cobra.CheckErr(err)
+			digest, err := digestFile(contentPath, chunkSize)
+			cobra.CheckErr(err)
+			md, err = withContentDigest(md, digest)
+			cobra.CheckErr(err)
+		}
+
+		// describe the columns with the schema inferred from a sample of the dataset
+		samplePath, err := cmd.Flags().GetString("infer-schema")
+		cobra.CheckErr(err)
+		if samplePath != "" && batch {
+			cobra.CheckErr(fmt.Errorf("--infer-schema only applies to a single metadata document"))
+		}
+		if samplePath != "" {
+			sampleRows, err := cmd.Flags().GetInt("sample-rows")
+			cobra.CheckErr(err)
+			pii, err := cmd.Flags().GetStringArray("pii")
+			cobra.CheckErr(err)
+			columns, err := inferColumns(samplePath, sampleRows)
+			cobra.CheckErr(err)
+			md, err = withColumns(md, columns, pii)
+			cobra.CheckErr(err)
+		}
+
 		// get collections and encode to base64
 		collections, err := cmd.Flags().GetStringArray("collection")
 		public, err := cmd.Flags().GetBool("public")
@@ -38,29 +92,105 @@ to quickly create a Cobra application.`,
 		cobra.CheckErr(err)
 		bs, err := json.Marshal(collections)
 		cobra.CheckErr(err)
+		retention, err := retentionFromFlags(cmd)
+		cobra.CheckErr(err)
 
-		transientData := map[string][]byte{
-			"metadata":    md,
-			"collections": bs,
-		}
-
+		// with --prepare only the certificate is needed, the proposal is signed offline
+		prepare, err := cmd.Flags().GetString("prepare")
+		cobra.CheckErr(err)
 		gatewayConfig := getGatewayConfig()
+		if prepare != "" {
+			gatewayConfig = offlineGatewayConfig(gatewayConfig)
+		}
 		gw := gateway.NewFabricGateway()
 		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
 		defer gw.Client.Close()
 		defer gw.Gateway.Close()
-		_, err = gw.GetContract("basic", "mychannel").Submit(
-			"Register",
-			client.WithTransient(transientData),
-		)
+		contract := getContract(gw)
+
+		if !batch {
+			transientData := map[string][]byte{
+				"metadata":    md,
+				"collections": bs,
+				"retention":   retention,
+			}
+			if prepare != "" {
+				cobra.CheckErr(prepareProposal(contract, prepare, "Register", client.WithTransient(transientData)))
+				return
+			}
+			_, err = contract.Submit(
+				"Register",
+				client.WithTransient(transientData),
+			)
+			cobra.CheckErr(err)
+			return
+		}
+
+		// register many datasets in as few transactions as the limits allow
+		batchSize, err := cmd.Flags().GetInt("batch-size")
+		cobra.CheckErr(err)
+		if batchSize < 1 || batchSize > maxBatchSize {
+			cobra.CheckErr(fmt.Errorf("--batch-size must be between 1 and %d", maxBatchSize))
+		}
+		batchBytes, err := cmd.Flags().GetInt("batch-bytes")
 		cobra.CheckErr(err)
+		batches := splitBatches(docs, batchSize, batchBytes)
+		if prepare != "" {
+			if len(batches) != 1 {
+				cobra.CheckErr(fmt.Errorf("--prepare needs the datasets to fit one transaction, got %d batches", len(batches)))
+			}
+			transientData := map[string][]byte{
+				"metadata":    batches[0],
+				"collections": bs,
+				"retention":   retention,
+			}
+			cobra.CheckErr(prepareProposal(contract, prepare, "RegisterBatch", client.WithTransient(transientData)))
+			return
+		}
+		registered := 0
+		for i, payload := range batches {
+			transientData := map[string][]byte{
+				"metadata":    payload,
+				"collections": bs,
+				"retention":   retention,
+			}
+			result, err := contract.Submit(
+				"RegisterBatch",
+				client.WithTransient(transientData),
+			)
+			if err != nil {
+				if rejection, ok := readBatchRejection(err); ok {
+					for _, failure := range rejection.Failures {
+						fmt.Fprintf(os.Stderr, "Item %d %s: %s\n", registered+failure.Index, failure.ID, failure.Error)
+					}
+					cobra.CheckErr(fmt.Errorf("batch %d of %d rejected, %d of its %d items are invalid and none of them was registered, %d datasets were registered before", i+1, len(batches), len(rejection.Failures), rejection.Total, registered))
+				}
+				cobra.CheckErr(fmt.Errorf("batch %d of %d failed after %d datasets were registered: %v", i+1, len(batches), registered, err))
+			}
+			var items []json.RawMessage
+			cobra.CheckErr(json.Unmarshal(result, &items))
+			registered += len(items)
+			fmt.Printf("Result: %s\n", string(result))
+		}
+		fmt.Printf("Registered %d datasets in %d transactions\n", registered, len(batches))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(registerCmd)
 
-	registerCmd.Flags().String("metadata", "", "path to metadata file")
+	registerCmd.Flags().String("metadata", "", "path to metadata file, multi-document file or directory of metadata files")
 	registerCmd.Flags().StringArray("collection", []string{}, "collections in which to register the metadata")
 	registerCmd.Flags().BoolP("public", "p", true, "register in public ledger")
+	registerCmd.Flags().String("content-file", "", "path to dataset file whose digest is registered with the metadata")
+	registerCmd.Flags().Int64("chunk-size", defaultChunkSize, "chunk size of the Merkle tree in bytes")
+	registerCmd.Flags().String("infer-schema", "", "path to a sample CSV file of the dataset to infer the column schema from")
+	registerCmd.Flags().Int("sample-rows", defaultSampleRows, "number of CSV rows read to infer column types")
+	registerCmd.Flags().StringArray("pii", []string{}, "column holding personal data, left out of public copies")
+	registerCmd.Flags().String("format", formatLedger, "format of the metadata files, ledger, dcat or ckan")
+	registerCmd.Flags().String("id-prefix", "", "organisation prefix of imported dataset IDs not of the form org/path")
+	addRetentionFlags(registerCmd)
+	registerCmd.Flags().Int("batch-size", defaultBatchSize, "maximum number of datasets per transaction")
+	registerCmd.Flags().Int("batch-bytes", defaultBatchBytes, "maximum size of metadata per transaction in bytes")
+	registerCmd.Flags().String("prepare", "", "write the unsigned proposal to this file instead of submitting, see \"sign\"")
 }
@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// retentionCmd represents the retention command
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Report, renew and purge expiring datasets",
+	Long: `Manage the retention of the datasets of your organisation.
+
+Datasets registered with a retention class other than "permanent", or with an
+explicit expiry, are hidden from queries once expired and removed from every
+collection by "retention purge". Use "retention report" to find datasets
+nearing expiry and "retention renew" to extend them.`,
+}
+
+var retentionReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "List datasets expired or expiring within the given number of days",
+	Run: func(cmd *cobra.Command, args []string) {
+		days, err := cmd.Flags().GetInt("days")
+		cobra.CheckErr(err)
+		max, err := cmd.Flags().GetInt("max")
+		cobra.CheckErr(err)
+
+		evaluateAccess("QueryExpiring", strconv.Itoa(days), strconv.Itoa(max))
+	},
+}
+
+var retentionRenewCmd = &cobra.Command{
+	Use:   "renew [id]",
+	Short: "Set a new retention for a dataset, counted from now",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		retention, err := retentionFromFlags(cmd)
+		cobra.CheckErr(err)
+
+		submitAccess("RenewRetention", map[string][]byte{"retention": retention}, args[0])
+	},
+}
+
+var retentionPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge expired datasets of your organisation",
+	Run: func(cmd *cobra.Command, args []string) {
+		max, err := cmd.Flags().GetInt("max")
+		cobra.CheckErr(err)
+
+		submitAccess("PurgeExpired", nil, strconv.Itoa(max))
+	},
+}
+
+func addRetentionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("retention-class", "", "retention class, short, standard, long or permanent")
+	cmd.Flags().String("expires-at", "", "RFC3339 expiry, overrides the period of the retention class")
+}
+
+// retentionFromFlags encodes the retention flags for transient "retention"
+func retentionFromFlags(cmd *cobra.Command) ([]byte, error) {
+	class, err := cmd.Flags().GetString("retention-class")
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := cmd.Flags().GetString("expires-at")
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{"class": class, "expiresAt": expiresAt})
+}
+
+func init() {
+	rootCmd.AddCommand(retentionCmd)
+	retentionCmd.AddCommand(retentionReportCmd, retentionRenewCmd, retentionPurgeCmd)
+
+	retentionReportCmd.Flags().Int("days", 30, "number of days ahead to report")
+	retentionReportCmd.Flags().Int("max", 100, "maximum number of datasets to report")
+	addRetentionFlags(retentionRenewCmd)
+	retentionPurgeCmd.Flags().Int("max", 100, "maximum number of datasets to purge in one transaction")
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,12 +29,11 @@ to quickly create a Cobra application.`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+// This is called by main.main(). It only needs to happen once to the rootCmd. It returns
+// the exit code of the command, so that deferred calls of the command have run before
+// the process exits.
+func Execute() int {
+	return exitCode(rootCmd.Execute())
 }
 
 // mspID        = "Org1MSP"
@@ -52,6 +52,9 @@ func init() {
 
 	rootCmd.PersistentFlags().String("as", "org1.user1", "as user (default is org1.user1)")
 	rootCmd.PersistentFlags().String("peer", "org1.peer0", "(default is org1.peer0)")
+	rootCmd.PersistentFlags().String("channel", "mychannel", "channel of the chaincode")
+	rootCmd.PersistentFlags().String("chaincode", "basic", "name of the dataset chaincode")
+	rootCmd.PersistentFlags().String("profile", "", "profile of the config file bundling identity, peer, channel and chaincode")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -81,6 +84,12 @@ func init() {
 	viper.SetDefault("org2.peer0.gateway", "peer0.org2.example.com")
 }
 
+// environment variables holding the PEM encoded certificate and private key of the client
+const (
+	envCertPEM = "FABRIC_CERT_PEM"
+	envKeyPEM  = "FABRIC_KEY_PEM"
+)
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgPath := os.Getenv("CONFIG_PATH"); cfgPath != "" {
@@ -94,18 +103,17 @@ func initConfig() {
 	viper.SetConfigName(".config-fabric")
 	viper.SetConfigType("yaml")
 
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
 	viper.ReadInConfig()
 }
 
 func getGatewayConfig() gateway.FabricGatewayConfiguration {
-	user, err := rootCmd.PersistentFlags().GetString("as")
-	cobra.CheckErr(err)
-	peer, err := rootCmd.PersistentFlags().GetString("peer")
-	cobra.CheckErr(err)
+	user := resolveSetting("as").Value
+	peer := resolveSetting("peer").Value
 	org := strings.Split(user, ".")[0]
 
-	return gateway.FabricGatewayConfiguration{
+	config := gateway.FabricGatewayConfiguration{
 		MspID:        viper.GetString(org + ".mspID"),
 		CertPath:     viper.GetString(user + ".certPath"),
 		KeyPath:      viper.GetString(user + ".keyPath"),
@@ -113,4 +121,22 @@ func getGatewayConfig() gateway.FabricGatewayConfiguration {
 		PeerEndpoint: viper.GetString(peer + ".endpoint"),
 		PeerGateway:  viper.GetString(peer + ".gateway"),
 	}
+
+	// PEM in the environment takes precedence over a wallet, which takes precedence over PEM files
+	if os.Getenv(envCertPEM) != "" {
+		config.Identity = gateway.EnvPEM{MspID: config.MspID, CertVar: envCertPEM, KeyVar: envKeyPEM}
+	} else if wallet := viper.GetString(user + ".wallet"); wallet != "" {
+		label := viper.GetString(user + ".label")
+		if label == "" {
+			label = strings.TrimPrefix(user, org+".")
+		}
+		config.Identity = gateway.Wallet{Path: wallet, Label: label}
+	}
+
+	return config
+}
+
+// getContract returns the dataset chaincode on the resolved channel
+func getContract(gw *gateway.FabricGateway) *client.Contract {
+	return gw.GetContract(resolveSetting("chaincode").Value, resolveSetting("channel").Value)
 }
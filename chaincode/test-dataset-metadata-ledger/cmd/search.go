@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search [selector]",
+	Short: "Search metadata with a CouchDB selector",
+	Long: `Search the public ledger or a named collection with a CouchDB selector,
+one page at a time. For example:
+
+test-dataset-metadata-ledger search '{"organisation":"org1.example.com"}' --page-size 10
+
+The result carries a bookmark, pass it with --bookmark to fetch the next page.
+This requires CouchDB as the state database.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		coll, err := cmd.Flags().GetString("collection")
+		cobra.CheckErr(err)
+		pageSize, err := cmd.Flags().GetInt("page-size")
+		cobra.CheckErr(err)
+		bookmark, err := cmd.Flags().GetString("bookmark")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		result, err := getContract(gw).Evaluate(
+			"Search",
+			client.WithArguments(coll, args[0], strconv.Itoa(pageSize), bookmark),
+		)
+		cobra.CheckErr(err)
+		fmt.Printf("Result: %s\n", string(result))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringP("collection", "c", "", "collection in which to search data")
+	searchCmd.Flags().Int("page-size", 10, "number of records per page")
+	searchCmd.Flags().String("bookmark", "", "bookmark returned by the previous page")
+}
@@ -0,0 +1,98 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// termsCmd represents the terms command
+var termsCmd = &cobra.Command{
+	Use:   "terms",
+	Short: "Attach and accept usage terms of datasets",
+	Long: `The endpoint of a dataset is only released to organisations that accepted its
+current usage terms. Owners attach terms with "terms set", for example:
+
+{"allowedPurposes": ["research"], "attribution": "Org1", "noRedistribution": true,
+ "expiresAt": "2025-01-01T00:00:00Z"}
+
+Consumers review them with "terms show" and accept them with "terms accept",
+which binds their identity to the hash of the terms. Terms belong to the owning
+organisation, so consumers name it by MSP ID. Access requests must be filed by
+the identity that accepted the terms.`,
+}
+
+var termsSetCmd = &cobra.Command{
+	Use:   "set [dataset-id] [terms-file]",
+	Short: "Attach usage terms to a dataset of your organisation",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		terms, err := os.ReadFile(args[1])
+		cobra.CheckErr(err)
+
+		submitAccess("SetTerms", nil, args[0], string(terms))
+	},
+}
+
+var termsShowCmd = &cobra.Command{
+	Use:   "show [dataset-id] [owner-msp-id]",
+	Short: "Show the current usage terms of a dataset",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		evaluateAccess("GetTerms", args[0], args[1])
+	},
+}
+
+var termsAcceptCmd = &cobra.Command{
+	Use:   "accept [dataset-id] [owner-msp-id]",
+	Short: "Accept the usage terms of a dataset for a purpose",
+	Long: `Accept the terms of a dataset. Without --hash, the current terms are fetched
+and printed first and their hash is accepted.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		purpose, err := cmd.Flags().GetString("purpose")
+		cobra.CheckErr(err)
+		hash, err := cmd.Flags().GetString("hash")
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+		contract := getContract(gw)
+
+		if hash == "" {
+			result, err := contract.Evaluate("GetTerms", client.WithArguments(args[0], args[1]))
+			cobra.CheckErr(err)
+			fmt.Printf("Terms: %s\n", string(result))
+			var terms struct {
+				Hash string `json:"hash"`
+			}
+			cobra.CheckErr(json.Unmarshal(result, &terms))
+			hash = terms.Hash
+		}
+
+		result, err := contract.Submit(
+			"AcceptTerms",
+			client.WithArguments(args[0], args[1], hash, purpose),
+		)
+		cobra.CheckErr(err)
+		fmt.Printf("Result: %s\n", string(result))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(termsCmd)
+	termsCmd.AddCommand(termsSetCmd, termsShowCmd, termsAcceptCmd)
+
+	termsAcceptCmd.Flags().String("purpose", "", "purpose the dataset is used for")
+	termsAcceptCmd.Flags().String("hash", "", "hash of the terms to accept, the current terms if empty")
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update registered metadata on the ledger",
+	Long: `Replace the metadata of a registered dataset with a new revision.
+
+The previous revision is kept in the implicit collection of the owner, and
+every public copy written at registration is updated in step.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mdPath, err := cmd.Flags().GetString("metadata")
+		cobra.CheckErr(err)
+		md, err := os.ReadFile(mdPath)
+		cobra.CheckErr(err)
+
+		transientData := map[string][]byte{
+			"metadata": md,
+		}
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+		_, err = getContract(gw).Submit(
+			"Update",
+			client.WithTransient(transientData),
+		)
+		cobra.CheckErr(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().String("metadata", "", "path to metadata file")
+}
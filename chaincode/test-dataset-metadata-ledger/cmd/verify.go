@@ -0,0 +1,53 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [dataset-id] [file]",
+	Short: "Verify a local copy of a dataset against its registered digest",
+	Long: `Hash a local file, as SHA-256 and as the Merkle root of its chunks, and check
+the digests against the ones registered for the dataset. The outcome, match or
+mismatch, is recorded on the ledger.
+
+The chunk size must be the one the dataset was registered with.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		coll, err := cmd.Flags().GetString("collection")
+		cobra.CheckErr(err)
+		chunkSize, err := cmd.Flags().GetInt64("chunk-size")
+		cobra.CheckErr(err)
+
+		digest, err := digestFile(args[1], chunkSize)
+		cobra.CheckErr(err)
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		result, err := getContract(gw).Submit(
+			"VerifyDataset",
+			client.WithArguments(coll, args[0], digest.SHA256, digest.MerkleRoot),
+		)
+		cobra.CheckErr(err)
+		fmt.Printf("Result: %s\n", string(result))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringP("collection", "c", "", "collection holding the dataset metadata")
+	verifyCmd.Flags().Int64("chunk-size", defaultChunkSize, "chunk size of the Merkle tree in bytes")
+}
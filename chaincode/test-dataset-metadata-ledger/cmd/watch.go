@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/gateway"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch [event...]",
+	Short: "Stream dataset events emitted by the ledger",
+	Long: `Print the chaincode events emitted on every dataset state change, such as
+DatasetRegistered, DatasetUpdated, DatasetRetired and AccessGranted, until
+interrupted. Event names given as arguments restrict the output to those events.
+
+Streaming starts from the next block unless --start-block is given. With
+--checkpoint, the position of the last printed event is kept in a file and the
+stream resumes after it on the next run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		startBlock, err := cmd.Flags().GetInt64("start-block")
+		cobra.CheckErr(err)
+		checkpointFile, err := cmd.Flags().GetString("checkpoint")
+		cobra.CheckErr(err)
+
+		options := []client.ChaincodeEventsOption{}
+		var checkpointer *client.FileCheckpointer
+		if checkpointFile != "" {
+			checkpointer, err = client.NewFileCheckpointer(checkpointFile)
+			cobra.CheckErr(err)
+			defer checkpointer.Close()
+			options = append(options, client.WithCheckpoint(checkpointer))
+		}
+		// The checkpoint takes precedence once it holds a position
+		if startBlock >= 0 {
+			options = append(options, client.WithStartBlock(uint64(startBlock)))
+		}
+
+		gatewayConfig := getGatewayConfig()
+		gw := gateway.NewFabricGateway()
+		cobra.CheckErr(gw.WithConfiguration(gatewayConfig))
+		defer gw.Client.Close()
+		defer gw.Gateway.Close()
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		events, err := gw.Gateway.GetNetwork(resolveSetting("channel").Value).ChaincodeEvents(ctx, resolveSetting("chaincode").Value, options...)
+		cobra.CheckErr(err)
+
+		for event := range events {
+			if len(args) == 0 || containsArg(args, event.EventName) {
+				fmt.Println(formatEvent(event))
+			}
+			if checkpointer != nil {
+				cobra.CheckErr(checkpointer.CheckpointChaincodeEvent(event))
+			}
+		}
+	},
+}
+
+// formatEvent renders an event on one line, with the payload as emitted by the chaincode
+func formatEvent(event *client.ChaincodeEvent) string {
+	return fmt.Sprintf("Block %d, Tx %s, %s: %s", event.BlockNumber, event.TransactionID, event.EventName, event.Payload)
+}
+
+func containsArg(args []string, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Int64("start-block", -1, "block number to start streaming from")
+	watchCmd.Flags().String("checkpoint", "", "file to keep the stream position in")
+}
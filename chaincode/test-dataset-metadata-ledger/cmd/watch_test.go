@@ -0,0 +1,41 @@
+/*
+Copyright © 2022 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+func TestWatchEvents(t *testing.T) {
+	event := &client.ChaincodeEvent{
+		BlockNumber:   7,
+		TransactionID: "tx1",
+		EventName:     "DatasetRegistered",
+		Payload:       []byte(`{"type":"DatasetRegistered","id":"org1.example.com/data001","owner":"Org1MSP","collections":[""]}`),
+	}
+
+	want := `Block 7, Tx tx1, DatasetRegistered: {"type":"DatasetRegistered","id":"org1.example.com/data001","owner":"Org1MSP","collections":[""]}`
+	if got := formatEvent(event); got != want {
+		t.Errorf("formatEvent = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "empty", args: nil, want: false},
+		{name: "named", args: []string{"DatasetUpdated", "DatasetRegistered"}, want: true},
+		{name: "other", args: []string{"AccessGranted"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsArg(tt.args, event.EventName); got != tt.want {
+				t.Errorf("containsArg(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoPrivateKey is returned when a key store holds no private key at all
+	ErrNoPrivateKey = errors.New("no private key found")
+	// ErrKeyMismatch is returned when no private key matches the public key of the certificate
+	ErrKeyMismatch = errors.New("no private key matches the certificate")
+	// ErrIdentityNotFound is returned when a wallet holds no identity with the requested label
+	ErrIdentityNotFound = errors.New("identity not found")
+)
+
+// IdentityError reports a failure to load the client identity or signer from a source.
+type IdentityError struct {
+	// Source describes where the identity was loaded from, e.g. a file path or a wallet label
+	Source string
+	Err    error
+}
+
+func (e *IdentityError) Error() string {
+	return fmt.Sprintf("failed to load identity from %s: %v", e.Source, e.Err)
+}
+
+func (e *IdentityError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError reports a setting of the gateway configuration that cannot be used, e.g. an unreadable
+// TLS CA certificate.
+type ConfigError struct {
+	// Setting names the configuration entry, e.g. "TLS CA certificate"
+	Setting string
+	Value   string
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s %s: %v", e.Setting, e.Value, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionError reports a failure to connect to the Gateway peer.
+type ConnectionError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("failed to connect to gateway peer %s: %v", e.Endpoint, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
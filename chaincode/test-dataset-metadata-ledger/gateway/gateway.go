@@ -7,22 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package gateway
 
 import (
-	"bytes"
-	"context"
 	"crypto/x509"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
-	"path"
 	"time"
 
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
-	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
 )
 
 type FabricGatewayConfiguration struct {
@@ -32,6 +25,8 @@ type FabricGatewayConfiguration struct {
 	TlsCertPath  string
 	PeerEndpoint string
 	PeerGateway  string
+	// Identity overrides MspID, CertPath and KeyPath if set
+	Identity IdentitySource
 }
 
 type FabricGateway struct {
@@ -39,49 +34,91 @@ type FabricGateway struct {
 	Gateway *client.Gateway
 }
 
-var now = time.Now()
-var assetId = fmt.Sprintf("asset%d", now.Unix()*1e3+int64(now.Nanosecond())/1e6)
-
 func NewFabricGateway() *FabricGateway {
 	return &FabricGateway{}
 }
 
+// Connect returns a gateway connected with the configuration, to be closed by the caller.
+func Connect(config FabricGatewayConfiguration) (*FabricGateway, error) {
+	fg := NewFabricGateway()
+	if err := fg.WithConfiguration(config); err != nil {
+		return nil, err
+	}
+
+	return fg, nil
+}
+
+// LoadIdentity loads the identity and signer of the configured source, the PEM files of the
+// configuration by default.
+func (config FabricGatewayConfiguration) LoadIdentity() (*identity.X509Identity, identity.Sign, error) {
+	if config.Identity != nil {
+		return config.Identity.Load()
+	}
+
+	return PEMFiles{MspID: config.MspID, CertPath: config.CertPath, KeyPath: config.KeyPath}.Load()
+}
+
 func (fg *FabricGateway) WithConfiguration(config FabricGatewayConfiguration) error {
-	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	fg.Client = newGrpcConnection(config)
+	id, sign, err := config.LoadIdentity()
+	if err != nil {
+		return err
+	}
+	options := []client.ConnectOption{}
+	// without a signer, proposals, transactions and commits must be signed offline
+	if sign != nil {
+		options = append(options, client.WithSign(sign))
+	}
 
-	id := newIdentity(config)
-	sign := newSign(config)
+	// The gRPC client connection should be shared by all Gateway connections to this endpoint
+	connection, err := newGrpcConnection(config)
+	if err != nil {
+		return err
+	}
 
 	// Create a Gateway connection for a specific client identity
 	gw, err := client.Connect(
 		id,
-		client.WithSign(sign),
-		client.WithClientConnection(fg.Client),
-		// Default timeouts for different gRPC calls
-		client.WithEvaluateTimeout(5*time.Second),
-		client.WithEndorseTimeout(15*time.Second),
-		client.WithSubmitTimeout(5*time.Second),
-		client.WithCommitStatusTimeout(1*time.Minute),
+		append(options,
+			client.WithClientConnection(connection),
+			// Default timeouts for different gRPC calls
+			client.WithEvaluateTimeout(5*time.Second),
+			client.WithEndorseTimeout(15*time.Second),
+			client.WithSubmitTimeout(5*time.Second),
+			client.WithCommitStatusTimeout(1*time.Minute),
+		)...,
 	)
 	if err != nil {
-		return err
+		connection.Close()
+		return &ConnectionError{Endpoint: config.PeerEndpoint, Err: err}
 	}
+	fg.Client = connection
 	fg.Gateway = gw
 
 	return nil
 }
 
+// Close closes the Gateway and its gRPC connection.
+func (fg *FabricGateway) Close() error {
+	if fg.Gateway != nil {
+		fg.Gateway.Close()
+	}
+	if fg.Client != nil {
+		return fg.Client.Close()
+	}
+
+	return nil
+}
+
 func (fg *FabricGateway) GetContract(chaincode string, channel string) *client.Contract {
 	network := fg.Gateway.GetNetwork(channel)
 	return network.GetContract(chaincode)
 }
 
 // newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection(config FabricGatewayConfiguration) *grpc.ClientConn {
+func newGrpcConnection(config FabricGatewayConfiguration) (*grpc.ClientConn, error) {
 	certificate, err := loadCertificate(config.TlsCertPath)
 	if err != nil {
-		panic(err)
+		return nil, &ConfigError{Setting: "TLS CA certificate", Value: config.TlsCertPath, Err: err}
 	}
 
 	certPool := x509.NewCertPool()
@@ -90,25 +127,10 @@ func newGrpcConnection(config FabricGatewayConfiguration) *grpc.ClientConn {
 
 	connection, err := grpc.Dial(config.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
-		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
-	}
-
-	return connection
-}
-
-// newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity(config FabricGatewayConfiguration) *identity.X509Identity {
-	certificate, err := loadCertificate(config.CertPath)
-	if err != nil {
-		panic(err)
+		return nil, &ConnectionError{Endpoint: config.PeerEndpoint, Err: err}
 	}
 
-	id, err := identity.NewX509Identity(config.MspID, certificate)
-	if err != nil {
-		panic(err)
-	}
-
-	return id
+	return connection, nil
 }
 
 func loadCertificate(filename string) (*x509.Certificate, error) {
@@ -118,155 +140,3 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 	}
 	return identity.CertificateFromPEM(certificatePEM)
 }
-
-// newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign(config FabricGatewayConfiguration) identity.Sign {
-	files, err := os.ReadDir(config.KeyPath)
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
-	}
-	privateKeyPEM, err := os.ReadFile(path.Join(config.KeyPath, files[0].Name()))
-
-	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
-	}
-
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
-	if err != nil {
-		panic(err)
-	}
-
-	sign, err := identity.NewPrivateKeySign(privateKey)
-	if err != nil {
-		panic(err)
-	}
-
-	return sign
-}
-
-// This type of transaction would typically only be run once by an application the first time it was started after its
-// initial deployment. A new version of the chaincode deployed later would likely not need to run an "init" function.
-func initLedger(contract *client.Contract) {
-	fmt.Printf("\n--> Submit Transaction: InitLedger, function creates the initial set of assets on the ledger \n")
-
-	_, err := contract.SubmitTransaction("InitLedger")
-	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
-	}
-
-	fmt.Printf("*** Transaction committed successfully\n")
-}
-
-// Evaluate a transaction to query ledger state.
-func getAllAssets(contract *client.Contract) {
-	fmt.Println("\n--> Evaluate Transaction: GetAllAssets, function returns all the current assets on the ledger")
-
-	evaluateResult, err := contract.EvaluateTransaction("GetAllAssets")
-	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
-	}
-	result := formatJSON(evaluateResult)
-
-	fmt.Printf("*** Result:%s\n", result)
-}
-
-// Submit a transaction synchronously, blocking until it has been committed to the ledger.
-func createAsset(contract *client.Contract) {
-	fmt.Printf("\n--> Submit Transaction: CreateAsset, creates new asset with ID, Color, Size, Owner and AppraisedValue arguments \n")
-
-	_, err := contract.SubmitTransaction("CreateAsset", assetId, "yellow", "5", "Tom", "1300")
-	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
-	}
-
-	fmt.Printf("*** Transaction committed successfully\n")
-}
-
-// Evaluate a transaction by assetID to query ledger state.
-func readAssetByID(contract *client.Contract) {
-	fmt.Printf("\n--> Evaluate Transaction: ReadAsset, function returns asset attributes\n")
-
-	evaluateResult, err := contract.EvaluateTransaction("ReadAsset", assetId)
-	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
-	}
-	result := formatJSON(evaluateResult)
-
-	fmt.Printf("*** Result:%s\n", result)
-}
-
-// Submit transaction asynchronously, blocking until the transaction has been sent to the orderer, and allowing
-// this thread to process the chaincode response (e.g. update a UI) without waiting for the commit notification
-func transferAssetAsync(contract *client.Contract) {
-	fmt.Printf("\n--> Async Submit Transaction: TransferAsset, updates existing asset owner")
-
-	submitResult, commit, err := contract.SubmitAsync("TransferAsset", client.WithArguments(assetId, "Mark"))
-	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction asynchronously: %w", err))
-	}
-
-	fmt.Printf("\n*** Successfully submitted transaction to transfer ownership from %s to Mark. \n", string(submitResult))
-	fmt.Println("*** Waiting for transaction commit.")
-
-	if commitStatus, err := commit.Status(); err != nil {
-		panic(fmt.Errorf("failed to get commit status: %w", err))
-	} else if !commitStatus.Successful {
-		panic(fmt.Errorf("transaction %s failed to commit with status: %d", commitStatus.TransactionID, int32(commitStatus.Code)))
-	}
-
-	fmt.Printf("*** Transaction committed successfully\n")
-}
-
-// Submit transaction, passing in the wrong number of arguments ,expected to throw an error containing details of any error responses from the smart contract.
-func exampleErrorHandling(contract *client.Contract) {
-	fmt.Println("\n--> Submit Transaction: UpdateAsset asset70, asset70 does not exist and should return an error")
-
-	_, err := contract.SubmitTransaction("UpdateAsset", "asset70", "blue", "5", "Tomoko", "300")
-	if err == nil {
-		panic("******** FAILED to return an error")
-	}
-
-	fmt.Println("*** Successfully caught the error:")
-
-	switch err := err.(type) {
-	case *client.EndorseError:
-		fmt.Printf("Endorse error for transaction %s with gRPC status %v: %s\n", err.TransactionID, status.Code(err), err)
-	case *client.SubmitError:
-		fmt.Printf("Submit error for transaction %s with gRPC status %v: %s\n", err.TransactionID, status.Code(err), err)
-	case *client.CommitStatusError:
-		if errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf("Timeout waiting for transaction %s commit status: %s", err.TransactionID, err)
-		} else {
-			fmt.Printf("Error obtaining commit status for transaction %s with gRPC status %v: %s\n", err.TransactionID, status.Code(err), err)
-		}
-	case *client.CommitError:
-		fmt.Printf("Transaction %s failed to commit with status %d: %s\n", err.TransactionID, int32(err.Code), err)
-	default:
-		panic(fmt.Errorf("unexpected error type %T: %w", err, err))
-	}
-
-	// Any error that originates from a peer or orderer node external to the gateway will have its details
-	// embedded within the gRPC status error. The following code shows how to extract that.
-	statusErr := status.Convert(err)
-
-	details := statusErr.Details()
-	if len(details) > 0 {
-		fmt.Println("Error Details:")
-
-		for _, detail := range details {
-			switch detail := detail.(type) {
-			case *gateway.ErrorDetail:
-				fmt.Printf("- address: %s, mspId: %s, message: %s\n", detail.Address, detail.MspId, detail.Message)
-			}
-		}
-	}
-}
-
-// Format JSON data
-func formatJSON(data []byte) string {
-	var prettyJSON bytes.Buffer
-	if err := json.Indent(&prettyJSON, data, "", "  "); err != nil {
-		panic(fmt.Errorf("failed to parse JSON: %w", err))
-	}
-	return prettyJSON.String()
-}
@@ -0,0 +1,43 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewGrpcConnectionTLSCertificate(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "missing", path: filepath.Join(dir, "missing.pem")},
+		{name: "not PEM", path: invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newGrpcConnection(FabricGatewayConfiguration{PeerEndpoint: "localhost:7051", TlsCertPath: tt.path})
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || configErr.Value != tt.path {
+				t.Fatalf("expected a ConfigError for %s, got %v", tt.path, err)
+			}
+			var connectionErr *ConnectionError
+			if errors.As(err, &connectionErr) {
+				t.Errorf("TLS certificate failure reported as ConnectionError: %v", err)
+			}
+		})
+	}
+}
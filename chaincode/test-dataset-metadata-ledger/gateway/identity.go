@@ -0,0 +1,209 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// IdentitySource loads the X.509 identity of a client and the signer of its private key. A nil
+// signer leaves signing to the caller, as for offline signing.
+type IdentitySource interface {
+	Load() (*identity.X509Identity, identity.Sign, error)
+}
+
+// Certificate loads the identity from a certificate file only, for a client whose private key is
+// kept on another machine and signs offline.
+type Certificate struct {
+	MspID    string
+	CertPath string
+}
+
+func (s Certificate) Load() (*identity.X509Identity, identity.Sign, error) {
+	certificatePEM, err := os.ReadFile(s.CertPath)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.CertPath, Err: err}
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.CertPath, Err: err}
+	}
+	id, err := identity.NewX509Identity(s.MspID, certificate)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.CertPath, Err: err}
+	}
+
+	return id, nil, nil
+}
+
+// PEMFiles loads the identity from a certificate file and a private key, where KeyPath is either a
+// key file or a key store directory holding the key among others.
+type PEMFiles struct {
+	MspID    string
+	CertPath string
+	KeyPath  string
+}
+
+func (s PEMFiles) Load() (*identity.X509Identity, identity.Sign, error) {
+	certificatePEM, err := os.ReadFile(s.CertPath)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.CertPath, Err: err}
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.CertPath, Err: err}
+	}
+
+	info, err := os.Stat(s.KeyPath)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: s.KeyPath, Err: err}
+	}
+	keyFiles := []string{s.KeyPath}
+	if info.IsDir() {
+		entries, err := os.ReadDir(s.KeyPath)
+		if err != nil {
+			return nil, nil, &IdentityError{Source: s.KeyPath, Err: err}
+		}
+		keyFiles = keyFiles[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				keyFiles = append(keyFiles, filepath.Join(s.KeyPath, entry.Name()))
+			}
+		}
+	}
+
+	keys := [][]byte{}
+	for _, keyFile := range keyFiles {
+		privateKeyPEM, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, &IdentityError{Source: keyFile, Err: err}
+		}
+		keys = append(keys, privateKeyPEM)
+	}
+
+	return newIdentity(s.MspID, certificate, keys, s.KeyPath)
+}
+
+// Wallet loads the identity with the given label from a file system wallet of fabric-sdk-go, where
+// each identity is stored as "<label>.id".
+type Wallet struct {
+	Path  string
+	Label string
+}
+
+// walletIdentity is the format of an X.509 identity in a fabric-sdk-go wallet
+type walletIdentity struct {
+	Version     int    `json:"version"`
+	MspID       string `json:"mspId"`
+	Type        string `json:"type"`
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+}
+
+func (s Wallet) Load() (*identity.X509Identity, identity.Sign, error) {
+	source := fmt.Sprintf("wallet %s with label %s", s.Path, s.Label)
+
+	bs, err := os.ReadFile(filepath.Join(s.Path, s.Label+".id"))
+	if os.IsNotExist(err) {
+		return nil, nil, &IdentityError{Source: source, Err: ErrIdentityNotFound}
+	}
+	if err != nil {
+		return nil, nil, &IdentityError{Source: source, Err: err}
+	}
+	var id walletIdentity
+	if err := json.Unmarshal(bs, &id); err != nil {
+		return nil, nil, &IdentityError{Source: source, Err: err}
+	}
+	if id.Type != "X.509" {
+		return nil, nil, &IdentityError{Source: source, Err: fmt.Errorf("unsupported identity type %q", id.Type)}
+	}
+
+	certificate, err := identity.CertificateFromPEM([]byte(id.Credentials.Certificate))
+	if err != nil {
+		return nil, nil, &IdentityError{Source: source, Err: err}
+	}
+
+	return newIdentity(id.MspID, certificate, [][]byte{[]byte(id.Credentials.PrivateKey)}, source)
+}
+
+// EnvPEM loads the identity from PEM encoded certificate and private key held in environment variables.
+type EnvPEM struct {
+	MspID   string
+	CertVar string
+	KeyVar  string
+}
+
+func (s EnvPEM) Load() (*identity.X509Identity, identity.Sign, error) {
+	source := fmt.Sprintf("environment variables %s and %s", s.CertVar, s.KeyVar)
+
+	certificatePEM, ok := os.LookupEnv(s.CertVar)
+	if !ok {
+		return nil, nil, &IdentityError{Source: source, Err: fmt.Errorf("%s is not set", s.CertVar)}
+	}
+	privateKeyPEM, ok := os.LookupEnv(s.KeyVar)
+	if !ok {
+		return nil, nil, &IdentityError{Source: source, Err: fmt.Errorf("%s is not set", s.KeyVar)}
+	}
+	certificate, err := identity.CertificateFromPEM([]byte(certificatePEM))
+	if err != nil {
+		return nil, nil, &IdentityError{Source: source, Err: err}
+	}
+
+	return newIdentity(s.MspID, certificate, [][]byte{[]byte(privateKeyPEM)}, source)
+}
+
+// newIdentity creates the identity of the certificate and the signer of the first key matching its
+// public key. Keys that fail to parse are skipped, as key stores may hold other files.
+func newIdentity(mspID string, certificate *x509.Certificate, keys [][]byte, source string) (*identity.X509Identity, identity.Sign, error) {
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		return nil, nil, &IdentityError{Source: source, Err: err}
+	}
+
+	parsed := 0
+	for _, privateKeyPEM := range keys {
+		privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+		if err != nil {
+			continue
+		}
+		parsed++
+		if !matchesCertificate(privateKey, certificate) {
+			continue
+		}
+
+		sign, err := identity.NewPrivateKeySign(privateKey)
+		if err != nil {
+			return nil, nil, &IdentityError{Source: source, Err: err}
+		}
+		return id, sign, nil
+	}
+
+	if parsed == 0 {
+		return nil, nil, &IdentityError{Source: source, Err: ErrNoPrivateKey}
+	}
+	return nil, nil, &IdentityError{Source: source, Err: ErrKeyMismatch}
+}
+
+// matchesCertificate reports whether the private key belongs to the public key of the certificate.
+func matchesCertificate(privateKey crypto.PrivateKey, certificate *x509.Certificate) bool {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+
+	return ok && publicKey.Equal(certificate.PublicKey)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// newTestPEM creates a self-signed certificate and its private key, PEM encoded
+func newTestPEM(t *testing.T) (certificatePEM []byte, privateKeyPEM []byte) {
+	t.Helper()
+	id, _, key := newTestIdentity(t, "Org1MSP")
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id.Credentials(), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func writeFile(t *testing.T, path string, content []byte) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// requireSigner checks that the signer signs with the private key of the identity's certificate
+func requireSigner(t *testing.T, id *identity.X509Identity, sign identity.Sign) {
+	t.Helper()
+	if id == nil || sign == nil {
+		t.Fatalf("identity = %v, signer = %v", id, sign)
+	}
+	certificate, err := identity.CertificateFromPEM(id.Credentials())
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := sign(digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(certificate.PublicKey.(*ecdsa.PublicKey), digest[:], signature) {
+		t.Error("signature does not verify against the certificate")
+	}
+}
+
+// requireIdentityError checks that the error is an IdentityError wrapping want, or with want in its message
+func requireIdentityError(t *testing.T, err error, want interface{}) {
+	t.Helper()
+	var identityErr *IdentityError
+	if !errors.As(err, &identityErr) {
+		t.Fatalf("expected an IdentityError, got %v", err)
+	}
+	switch want := want.(type) {
+	case error:
+		if !errors.Is(err, want) {
+			t.Errorf("error = %v, want %v", err, want)
+		}
+	case string:
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestPEMFiles(t *testing.T) {
+	dir := t.TempDir()
+	certificatePEM, privateKeyPEM := newTestPEM(t)
+	_, otherKeyPEM := newTestPEM(t)
+	certPath := writeFile(t, filepath.Join(dir, "signcerts", "cert.pem"), certificatePEM)
+
+	// a key store holding the key among another key and a file that is not a key
+	keystore := filepath.Join(dir, "keystore")
+	writeFile(t, filepath.Join(keystore, "0_other_sk"), otherKeyPEM)
+	writeFile(t, filepath.Join(keystore, "1_sk"), privateKeyPEM)
+	writeFile(t, filepath.Join(keystore, "2_notes.txt"), []byte("not a key"))
+	writeFile(t, filepath.Join(dir, "mismatch", "other_sk"), otherKeyPEM)
+	writeFile(t, filepath.Join(dir, "mismatch", "notes.txt"), []byte("not a key"))
+	writeFile(t, filepath.Join(dir, "nokey", "notes.txt"), []byte("not a key"))
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		certPath string
+		keyPath  string
+		err      interface{}
+	}{
+		{name: "key store", certPath: certPath, keyPath: keystore},
+		{name: "key file", certPath: certPath, keyPath: filepath.Join(keystore, "1_sk")},
+		{name: "no matching key", certPath: certPath, keyPath: filepath.Join(dir, "mismatch"), err: ErrKeyMismatch},
+		{name: "other key file", certPath: certPath, keyPath: filepath.Join(keystore, "0_other_sk"), err: ErrKeyMismatch},
+		{name: "no key", certPath: certPath, keyPath: filepath.Join(dir, "nokey"), err: ErrNoPrivateKey},
+		{name: "empty key store", certPath: certPath, keyPath: filepath.Join(dir, "empty"), err: ErrNoPrivateKey},
+		{name: "missing key store", certPath: certPath, keyPath: filepath.Join(dir, "missing"), err: os.ErrNotExist},
+		{name: "missing certificate", certPath: filepath.Join(dir, "missing.pem"), keyPath: keystore, err: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, sign, err := PEMFiles{MspID: "Org1MSP", CertPath: tt.certPath, KeyPath: tt.keyPath}.Load()
+			if tt.err != nil {
+				requireIdentityError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			requireSigner(t, id, sign)
+		})
+	}
+}
+
+func TestWallet(t *testing.T) {
+	dir := t.TempDir()
+	certificatePEM, privateKeyPEM := newTestPEM(t)
+	_, otherKeyPEM := newTestPEM(t)
+	writeWallet := func(label string, idType string, keyPEM []byte) {
+		var id walletIdentity
+		id.Version = 1
+		id.MspID = "Org2MSP"
+		id.Type = idType
+		id.Credentials.Certificate = string(certificatePEM)
+		id.Credentials.PrivateKey = string(keyPEM)
+		bs, err := json.Marshal(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, label+".id"), bs)
+	}
+	writeWallet("appUser", "X.509", privateKeyPEM)
+	writeWallet("idemixUser", "Idemix", privateKeyPEM)
+	writeWallet("mismatchUser", "X.509", otherKeyPEM)
+	writeFile(t, filepath.Join(dir, "brokenUser.id"), []byte("{"))
+
+	tests := []struct {
+		name  string
+		label string
+		err   interface{}
+	}{
+		{name: "identity", label: "appUser"},
+		{name: "missing label", label: "otherUser", err: ErrIdentityNotFound},
+		{name: "not X.509", label: "idemixUser", err: `unsupported identity type "Idemix"`},
+		{name: "key mismatch", label: "mismatchUser", err: ErrKeyMismatch},
+		{name: "not JSON", label: "brokenUser", err: "wallet " + dir + " with label brokenUser"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, sign, err := Wallet{Path: dir, Label: tt.label}.Load()
+			if tt.err != nil {
+				requireIdentityError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.MspID() != "Org2MSP" {
+				t.Errorf("MSP ID = %s, want Org2MSP", id.MspID())
+			}
+			requireSigner(t, id, sign)
+		})
+	}
+}
+
+func TestEnvPEM(t *testing.T) {
+	const (
+		certVar = "TEST_GATEWAY_CERT_PEM"
+		keyVar  = "TEST_GATEWAY_KEY_PEM"
+	)
+	certificatePEM, privateKeyPEM := newTestPEM(t)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  interface{}
+	}{
+		{name: "both set", env: map[string]string{certVar: string(certificatePEM), keyVar: string(privateKeyPEM)}},
+		{name: "certificate unset", env: map[string]string{keyVar: string(privateKeyPEM)}, err: certVar + " is not set"},
+		{name: "key unset", env: map[string]string{certVar: string(certificatePEM)}, err: keyVar + " is not set"},
+		{name: "key empty", env: map[string]string{certVar: string(certificatePEM), keyVar: ""}, err: ErrNoPrivateKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{certVar, keyVar} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			id, sign, err := EnvPEM{MspID: "Org1MSP", CertVar: certVar, KeyVar: keyVar}.Load()
+			if tt.err != nil {
+				requireIdentityError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			requireSigner(t, id, sign)
+		})
+	}
+}
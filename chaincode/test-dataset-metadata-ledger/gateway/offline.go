@@ -0,0 +1,251 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Kinds of artifacts of a transaction signed offline, in the order they are produced
+const (
+	ArtifactProposal    = "proposal"
+	ArtifactTransaction = "transaction"
+	ArtifactCommit      = "commit"
+)
+
+// ErrIdentityMismatch is returned when an artifact is signed by an identity other than its creator
+var ErrIdentityMismatch = errors.New("signing identity does not match the creator of the artifact")
+
+// Artifact is a serialized proposal, endorsed transaction or commit status request of a transaction
+// signed offline, stored as JSON so that it can be moved between machines.
+type Artifact struct {
+	Kind          string `json:"kind"`
+	TransactionID string `json:"transactionID"`
+	Channel       string `json:"channel"`
+	// Chaincode and function invoked, only known for proposals
+	Chaincode string `json:"chaincode,omitempty"`
+	Function  string `json:"function,omitempty"`
+	Bytes     []byte `json:"bytes"`
+	// Signature of the digest of Bytes, empty until signed
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// details of an artifact decoded from its bytes
+type artifactContent struct {
+	transactionID string
+	channel       string
+	chaincode     string
+	function      string
+	creator       []byte
+	// message whose SHA-256 hash is signed
+	signed []byte
+}
+
+// NewArtifact describes the serialized bytes of a proposal, transaction or commit.
+func NewArtifact(kind string, bs []byte) (*Artifact, error) {
+	content, err := decodeArtifact(kind, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Artifact{
+		Kind:          kind,
+		TransactionID: content.transactionID,
+		Channel:       content.channel,
+		Chaincode:     content.chaincode,
+		Function:      content.function,
+		Bytes:         bs,
+	}, nil
+}
+
+// ReadArtifact reads an artifact from a file and checks that its description matches its bytes.
+func ReadArtifact(path string) (*Artifact, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := new(Artifact)
+	if err := json.Unmarshal(bs, a); err != nil {
+		return nil, fmt.Errorf("failed to decode artifact %s: %w", path, err)
+	}
+
+	content, err := decodeArtifact(a.Kind, a.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact %s: %w", path, err)
+	}
+	if a.TransactionID != content.transactionID || a.Channel != content.channel ||
+		a.Chaincode != content.chaincode || a.Function != content.function {
+		return nil, fmt.Errorf("invalid artifact %s: description does not match its content", path)
+	}
+
+	return a, nil
+}
+
+// Write stores the artifact as JSON, readable by the owner only as a proposal may carry private data.
+func (a *Artifact) Write(path string) error {
+	bs, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(bs, '\n'), 0600)
+}
+
+// Digest returns the SHA-256 digest to sign, computed from the bytes of the artifact.
+func (a *Artifact) Digest() ([]byte, error) {
+	content, err := decodeArtifact(a.Kind, a.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(content.signed)
+
+	return digest[:], nil
+}
+
+// Sign signs the artifact with the identity, which must be the identity that created it.
+func (a *Artifact) Sign(id *identity.X509Identity, sign identity.Sign) error {
+	content, err := decodeArtifact(a.Kind, a.Bytes)
+	if err != nil {
+		return err
+	}
+	creator := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(content.creator, creator); err != nil {
+		return fmt.Errorf("failed to deserialize creator: %w", err)
+	}
+	if creator.GetMspid() != id.MspID() || !bytes.Equal(creator.GetIdBytes(), id.Credentials()) {
+		return ErrIdentityMismatch
+	}
+
+	digest := sha256.Sum256(content.signed)
+	signature, err := sign(digest[:])
+	if err != nil {
+		return err
+	}
+	a.Signature = signature
+
+	return nil
+}
+
+func decodeArtifact(kind string, bs []byte) (*artifactContent, error) {
+	switch kind {
+	case ArtifactProposal:
+		return decodeProposal(bs)
+	case ArtifactTransaction:
+		return decodeTransaction(bs)
+	case ArtifactCommit:
+		return decodeCommit(bs)
+	}
+
+	return nil, fmt.Errorf("unknown artifact kind %q", kind)
+}
+
+func decodeProposal(bs []byte) (*artifactContent, error) {
+	proposedTransaction := &gateway.ProposedTransaction{}
+	if err := proto.Unmarshal(bs, proposedTransaction); err != nil {
+		return nil, fmt.Errorf("failed to deserialize proposed transaction: %w", err)
+	}
+	proposalBytes := proposedTransaction.GetProposal().GetProposalBytes()
+	proposal := &peer.Proposal{}
+	if err := proto.Unmarshal(proposalBytes, proposal); err != nil {
+		return nil, fmt.Errorf("failed to deserialize proposal: %w", err)
+	}
+	content, err := decodeHeader(proposal.GetHeader())
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &peer.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(proposal.GetPayload(), payload); err != nil {
+		return nil, fmt.Errorf("failed to deserialize proposal payload: %w", err)
+	}
+	spec := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.GetInput(), spec); err != nil {
+		return nil, fmt.Errorf("failed to deserialize chaincode invocation: %w", err)
+	}
+	content.chaincode = spec.GetChaincodeSpec().GetChaincodeId().GetName()
+	if args := spec.GetChaincodeSpec().GetInput().GetArgs(); len(args) > 0 {
+		content.function = string(args[0])
+	}
+	content.signed = proposalBytes
+
+	return content, nil
+}
+
+func decodeTransaction(bs []byte) (*artifactContent, error) {
+	preparedTransaction := &gateway.PreparedTransaction{}
+	if err := proto.Unmarshal(bs, preparedTransaction); err != nil {
+		return nil, fmt.Errorf("failed to deserialize prepared transaction: %w", err)
+	}
+	payloadBytes := preparedTransaction.GetEnvelope().GetPayload()
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(payloadBytes, payload); err != nil {
+		return nil, fmt.Errorf("failed to deserialize transaction payload: %w", err)
+	}
+	headerBytes, err := proto.Marshal(payload.GetHeader())
+	if err != nil {
+		return nil, err
+	}
+	content, err := decodeHeader(headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	content.signed = payloadBytes
+
+	return content, nil
+}
+
+func decodeCommit(bs []byte) (*artifactContent, error) {
+	signedRequest := &gateway.SignedCommitStatusRequest{}
+	if err := proto.Unmarshal(bs, signedRequest); err != nil {
+		return nil, fmt.Errorf("failed to deserialize signed commit status request: %w", err)
+	}
+	request := &gateway.CommitStatusRequest{}
+	if err := proto.Unmarshal(signedRequest.GetRequest(), request); err != nil {
+		return nil, fmt.Errorf("failed to deserialize commit status request: %w", err)
+	}
+
+	return &artifactContent{
+		transactionID: request.GetTransactionId(),
+		channel:       request.GetChannelId(),
+		creator:       request.GetIdentity(),
+		signed:        signedRequest.GetRequest(),
+	}, nil
+}
+
+// decodeHeader reads the transaction ID, channel and creator of a proposal or transaction header
+func decodeHeader(headerBytes []byte) (*artifactContent, error) {
+	header := &common.Header{}
+	if err := proto.Unmarshal(headerBytes, header); err != nil {
+		return nil, fmt.Errorf("failed to deserialize header: %w", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(header.GetChannelHeader(), channelHeader); err != nil {
+		return nil, fmt.Errorf("failed to deserialize channel header: %w", err)
+	}
+	signatureHeader := &common.SignatureHeader{}
+	if err := proto.Unmarshal(header.GetSignatureHeader(), signatureHeader); err != nil {
+		return nil, fmt.Errorf("failed to deserialize signature header: %w", err)
+	}
+
+	return &artifactContent{
+		transactionID: channelHeader.GetTxId(),
+		channel:       channelHeader.GetChannelId(),
+		creator:       signatureHeader.GetCreator(),
+	}, nil
+}
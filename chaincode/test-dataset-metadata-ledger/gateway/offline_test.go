@@ -0,0 +1,216 @@
+/*
+Copyright 2021 IBM All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/hyperledger/fabric-protos-go-apiv2/msp"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestIdentity creates an identity with a self-signed certificate
+func newTestIdentity(t *testing.T, mspID string) (*identity.X509Identity, identity.Sign, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user1"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := identity.NewX509Identity(mspID, certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, err := identity.NewPrivateKeySign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, sign, key
+}
+
+func marshal(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	bs, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
+
+// testArtifacts serializes a proposal, transaction and commit status request created by the identity
+func testArtifacts(t *testing.T, id *identity.X509Identity) map[string][]byte {
+	t.Helper()
+	creator := marshal(t, &msp.SerializedIdentity{Mspid: id.MspID(), IdBytes: id.Credentials()})
+	header := &common.Header{
+		ChannelHeader:   marshal(t, &common.ChannelHeader{TxId: "tx1", ChannelId: "mychannel"}),
+		SignatureHeader: marshal(t, &common.SignatureHeader{Creator: creator}),
+	}
+	input := marshal(t, &peer.ChaincodeInvocationSpec{ChaincodeSpec: &peer.ChaincodeSpec{
+		ChaincodeId: &peer.ChaincodeID{Name: "basic"},
+		Input:       &peer.ChaincodeInput{Args: [][]byte{[]byte("Register")}},
+	}})
+	proposal := marshal(t, &peer.Proposal{
+		Header:  marshal(t, header),
+		Payload: marshal(t, &peer.ChaincodeProposalPayload{Input: input}),
+	})
+
+	return map[string][]byte{
+		ArtifactProposal: marshal(t, &gateway.ProposedTransaction{
+			TransactionId: "tx1",
+			Proposal:      &peer.SignedProposal{ProposalBytes: proposal},
+		}),
+		ArtifactTransaction: marshal(t, &gateway.PreparedTransaction{
+			TransactionId: "tx1",
+			Envelope:      &common.Envelope{Payload: marshal(t, &common.Payload{Header: header})},
+		}),
+		ArtifactCommit: marshal(t, &gateway.SignedCommitStatusRequest{
+			Request: marshal(t, &gateway.CommitStatusRequest{TransactionId: "tx1", ChannelId: "mychannel", Identity: creator}),
+		}),
+	}
+}
+
+func TestNewArtifact(t *testing.T) {
+	id, _, _ := newTestIdentity(t, "Org1MSP")
+	artifacts := testArtifacts(t, id)
+
+	tests := []struct {
+		kind      string
+		bytes     []byte
+		chaincode string
+		function  string
+		err       bool
+	}{
+		{kind: ArtifactProposal, bytes: artifacts[ArtifactProposal], chaincode: "basic", function: "Register"},
+		{kind: ArtifactTransaction, bytes: artifacts[ArtifactTransaction]},
+		{kind: ArtifactCommit, bytes: artifacts[ArtifactCommit]},
+		{kind: "block", bytes: artifacts[ArtifactCommit], err: true},
+		{kind: ArtifactProposal, bytes: []byte{0xff}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			a, err := NewArtifact(tt.kind, tt.bytes)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", a)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.TransactionID != "tx1" || a.Channel != "mychannel" || a.Chaincode != tt.chaincode || a.Function != tt.function {
+				t.Errorf("artifact = %+v", a)
+			}
+		})
+	}
+}
+
+func TestSignArtifact(t *testing.T) {
+	id, sign, key := newTestIdentity(t, "Org1MSP")
+	other, otherSign, _ := newTestIdentity(t, "Org1MSP")
+	artifacts := testArtifacts(t, id)
+
+	for _, kind := range []string{ArtifactProposal, ArtifactTransaction, ArtifactCommit} {
+		t.Run(kind, func(t *testing.T) {
+			a, err := NewArtifact(kind, artifacts[kind])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := a.Sign(other, otherSign); !errors.Is(err, ErrIdentityMismatch) {
+				t.Fatalf("signing as another identity returned %v", err)
+			}
+			if a.Signature != nil {
+				t.Fatal("signature set by another identity")
+			}
+
+			if err := a.Sign(id, sign); err != nil {
+				t.Fatal(err)
+			}
+			digest, err := a.Digest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ecdsa.VerifyASN1(&key.PublicKey, digest, a.Signature) {
+				t.Error("signature does not verify against the digest")
+			}
+
+			path := filepath.Join(t.TempDir(), kind+".json")
+			if err := a.Write(path); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ReadArtifact(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(read.Signature) != string(a.Signature) || read.TransactionID != a.TransactionID {
+				t.Errorf("read %+v, wrote %+v", read, a)
+			}
+		})
+	}
+
+	t.Run("digest of signed message", func(t *testing.T) {
+		a, err := NewArtifact(ArtifactCommit, artifacts[ArtifactCommit])
+		if err != nil {
+			t.Fatal(err)
+		}
+		request := &gateway.SignedCommitStatusRequest{}
+		if err := proto.Unmarshal(a.Bytes, request); err != nil {
+			t.Fatal(err)
+		}
+		want := sha256.Sum256(request.GetRequest())
+		digest, err := a.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(digest) != string(want[:]) {
+			t.Errorf("digest = %x, want %x", digest, want)
+		}
+	})
+
+	t.Run("tampered description", func(t *testing.T) {
+		a, err := NewArtifact(ArtifactProposal, artifacts[ArtifactProposal])
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Function = "Deregister"
+		path := filepath.Join(t.TempDir(), "proposal.json")
+		if err := a.Write(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadArtifact(path); err == nil {
+			t.Error("expected an error reading a tampered artifact")
+		}
+	})
+}
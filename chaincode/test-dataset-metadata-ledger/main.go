@@ -4,8 +4,12 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 */
 package main
 
-import "github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/cmd"
+import (
+	"os"
+
+	"github.com/jxu96/fabric-samples/chaincode/test-dataset-metadata-ledger/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	os.Exit(cmd.Execute())
 }